@@ -0,0 +1,169 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import "strconv"
+
+// indexAnonymousTypes declares the simple types defined inline by elements
+// and attributes as global types, which they then reference, so they are
+// generated with their enumerations and facets like any other. Their name
+// is made of the names of the definitions enclosing them, followed by the
+// name of the element or attribute and "Type", such as OrderStatusType for
+// the status attribute of the Order type. Unless inline types are asked
+// for, the complex types defined inline by local elements are declared the
+// same way, named without the "Type" suffix, such as OrderLine for the Line
+// element of the Order type. Such types are only named for the generated
+// code: they can't be referenced, mapped or given as xsi:type.
+func (g *GoWSDL) indexAnonymousTypes() {
+	g.anonymousTypes = make(map[interface{}]bool)
+	for _, schema := range g.wsdl.Types.Schemas {
+		a := &anonymousTypes{g: g, schema: schema, lift: !g.inlineTypes}
+
+		for _, element := range schema.Elements {
+			a.element("", element)
+		}
+		for _, complexType := range schema.ComplexTypes {
+			a.complexType(g.goNames[complexType], complexType)
+		}
+		for _, group := range schema.Groups {
+			a.particle(makePublic(group.Name), group.Content())
+		}
+		for _, attributeGroup := range schema.AttributeGroups {
+			a.attributes(makePublic(attributeGroup.Name), attributeGroup.Attributes)
+		}
+		a.attributes("", schema.Attributes)
+	}
+}
+
+// anonymousTypes walks the declarations of a schema, along with the name of
+// the definitions enclosing them.
+type anonymousTypes struct {
+	g      *GoWSDL
+	schema *XSDSchema
+	lift   bool
+}
+
+func (a *anonymousTypes) element(parent string, element *XSDElement) {
+	if element.Ref != "" {
+		return
+	}
+
+	name := a.g.goNames[element]
+	if name == "" {
+		name = parent + makePublic(element.Name)
+	}
+
+	if element.Type == "" && element.SimpleType != nil {
+		element.Type = a.g.declareSimpleType(a.schema, name+"Type", element.SimpleType)
+		element.SimpleType = nil
+	}
+
+	if element.ComplexType == nil {
+		return
+	}
+
+	complexType := element.ComplexType
+	// Global elements already name the struct of their inline complex type.
+	if a.lift && a.g.goNames[element] == "" {
+		element.Type = a.g.declareComplexType(a.schema, name, complexType)
+		element.ComplexType = nil
+		name = a.g.goNames[complexType]
+	}
+	a.complexType(name, complexType)
+}
+
+// Restricted types are walked as declared, before they are given the
+// content they inherit.
+func (a *anonymousTypes) complexType(name string, complexType *XSDComplexType) {
+	a.particle(name, complexType.Content())
+	a.attributes(name, complexType.Attributes)
+
+	extension := complexType.ComplexContent.Extension
+	a.particle(name, extension.Content())
+	a.attributes(name, extension.Attributes)
+
+	restriction := complexType.ComplexContent.Restriction
+	a.particle(name, restriction.Content())
+	a.attributes(name, restriction.Attributes)
+
+	a.attributes(name, complexType.SimpleContent.Extension.Attributes)
+	a.attributes(name, complexType.SimpleContent.Restriction.Attributes)
+}
+
+func (a *anonymousTypes) particle(parent string, particle *XSDParticle) {
+	if particle == nil {
+		return
+	}
+
+	switch particle.Kind {
+	case "element":
+		a.element(parent, particle.Element)
+	case "group":
+		if particle.Group.Ref == "" {
+			a.particle(parent, particle.Group.Content())
+		}
+	default:
+		for _, child := range particle.Particles {
+			a.particle(parent, child)
+		}
+	}
+}
+
+func (a *anonymousTypes) attributes(parent string, attributes []*XSDAttribute) {
+	for _, attribute := range attributes {
+		if attribute.Ref == "" && attribute.Type == "" && attribute.SimpleType != nil {
+			attribute.Type = a.g.declareSimpleType(a.schema, parent+makePublic(attribute.Name)+"Type", attribute.SimpleType)
+			attribute.SimpleType = nil
+		}
+	}
+}
+
+// Declares a simple type of a schema under the given name, numbered if it
+// is taken, and returns a reference to it.
+func (g *GoWSDL) declareSimpleType(schema *XSDSchema, name string, simpleType *XSDSimpleType) string {
+	ns := schema.TargetNamespace
+	local := g.freeTypeName(ns, name)
+
+	simpleType.Name = local
+	schema.SimpleType = append(schema.SimpleType, simpleType)
+	g.types[anonymousRef(ns, local)] = simpleType
+	g.anonymousTypes[simpleType] = true
+	g.namespaces[simpleType] = ns
+	g.declareGoName(simpleType, ns, local)
+
+	return anonymousRef(ns, local)
+}
+
+// Declares a complex type of a schema under the given name, numbered if it
+// is taken, and returns a reference to it.
+func (g *GoWSDL) declareComplexType(schema *XSDSchema, name string, complexType *XSDComplexType) string {
+	ns := schema.TargetNamespace
+	local := g.freeTypeName(ns, name)
+
+	complexType.Name = local
+	schema.ComplexTypes = append(schema.ComplexTypes, complexType)
+	g.types[anonymousRef(ns, local)] = complexType
+	g.anonymousTypes[complexType] = true
+	g.namespaces[complexType] = ns
+	g.declareGoName(complexType, ns, local)
+
+	return anonymousRef(ns, local)
+}
+
+// anonymousRef returns the reference to a type declared for an anonymous
+// one. Its local name isn't an NCName, so that no reference of a schema
+// resolves to a type the schema doesn't declare.
+func anonymousRef(ns, local string) string {
+	return qname(ns, "#"+local)
+}
+
+// Returns name, numbered if a type of the namespace already has it.
+func (g *GoWSDL) freeTypeName(ns, name string) string {
+	local := name
+	for i := 2; g.types[qname(ns, local)] != nil || g.types[anonymousRef(ns, local)] != nil; i++ {
+		local = name + strconv.Itoa(i)
+	}
+	return local
+}
@@ -0,0 +1,175 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+// attributeField is the field generated for an attribute, or a reference to
+// a global attribute, with the declaration it points to resolved.
+type attributeField struct {
+	Field string
+	// Local name of the attribute, and its namespace when qualified.
+	Name      string
+	Namespace string
+	GoType    string
+	Doc       string
+	Required  bool
+	Default   string
+	Fixed     string
+}
+
+// indexAttributes indexes the global attributes of every schema and records
+// the namespace of the attributes that must be qualified: global ones,
+// those declared with form="qualified" and those of schemas declaring
+// attributeFormDefault="qualified". References to the attributes of the
+// xml namespace, such as xml:lang, get a string declaration unless a schema
+// declares them.
+func (g *GoWSDL) indexAttributes() {
+	g.attributes = make(map[string]*XSDAttribute)
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, attribute := range schema.Attributes {
+			g.attributes[qname(schema.TargetNamespace, attribute.Name)] = attribute
+			g.namespaces[attribute] = schema.TargetNamespace
+		}
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		walkDeclarations(schema, nil, func(attribute *XSDAttribute) {
+			if attribute.Ref != "" {
+				ns, local := splitQName(attribute.Ref)
+				if ns == xmlNamespace && g.attributes[attribute.Ref] == nil {
+					declaration := &XSDAttribute{Name: local, Type: qname(xsdNamespace, "string")}
+					g.attributes[attribute.Ref] = declaration
+					g.namespaces[declaration] = ns
+				}
+				return
+			}
+
+			form := attribute.Form
+			if form == "" {
+				form = schema.AttributeFormDefault
+			}
+			if form == "qualified" {
+				g.namespaces[attribute] = schema.TargetNamespace
+			}
+		}, nil)
+	}
+}
+
+// lookupAttribute finds a global attribute by its {namespace}local name.
+// References without namespace fall back to the only attribute with the
+// same local name, if there is just one.
+func (g *GoWSDL) lookupAttribute(ref string) *XSDAttribute {
+	if attribute, ok := g.attributes[ref]; ok {
+		return attribute
+	}
+
+	var found *XSDAttribute
+	matches := 0
+	forUnqualified(ref, func(local string) {
+		for name, attribute := range g.attributes {
+			if _, l := splitQName(name); l == local {
+				found = attribute
+				matches++
+			}
+		}
+	})
+	if matches != 1 {
+		return nil
+	}
+	return found
+}
+
+// attributeFields returns the fields generated for attributes. Prohibited
+// attributes and references that don't resolve get none.
+func (g *GoWSDL) attributeFields(attributes []*XSDAttribute) []*attributeField {
+	var fields []*attributeField
+	for _, attribute := range attributes {
+		if attribute.Use == "prohibited" {
+			continue
+		}
+
+		declaration := attribute
+		if attribute.Ref != "" {
+			if declaration = g.lookupAttribute(attribute.Ref); declaration == nil {
+				continue
+			}
+		}
+
+		field := &attributeField{
+			Field:     makePublic(declaration.Name),
+			Name:      declaration.Name,
+			Namespace: g.namespaces[declaration],
+			GoType:    g.attributeGoType(declaration),
+			Doc:       attribute.Doc,
+			Required:  attribute.Use == "required",
+			Default:   attribute.Default,
+			Fixed:     attribute.Fixed,
+		}
+		// Required attributes are values, always encoded even when zero.
+		// encoding/xml never leaves out structs, optional ones are pointers.
+		if !field.Required && !g.omitsEmpty(declaration) {
+			field.GoType = pointer(field.GoType)
+		}
+		if field.Doc == "" {
+			field.Doc = declaration.Doc
+		}
+		// Values given on the reference take precedence.
+		if field.Default == "" && field.Fixed == "" {
+			field.Default, field.Fixed = declaration.Default, declaration.Fixed
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// Returns the Go type of an attribute declaration. Attributes without a type
+// are strings.
+func (g *GoWSDL) attributeGoType(attribute *XSDAttribute) string {
+	if attribute.Type != "" {
+		return g.toGoType(attribute.Type)
+	}
+	return "string"
+}
+
+// Runtime types that are structs.
+var runtimeStructTypes = map[string]bool{
+	"XSDDateTime":   true,
+	"XSDDate":       true,
+	"XSDTime":       true,
+	"XSDDuration":   true,
+	"XSDGYear":      true,
+	"XSDGYearMonth": true,
+	"XSDGMonth":     true,
+	"XSDGMonthDay":  true,
+	"XSDGDay":       true,
+}
+
+// Tells whether the value of an attribute is left out by encoding/xml when
+// empty, which it is unless its Go type is a struct: a union or a runtime
+// type such as XSDDateTime, or a restriction of one.
+func (g *GoWSDL) omitsEmpty(attribute *XSDAttribute) bool {
+	simpleType, ref := attribute.SimpleType, attribute.Type
+	seen := map[*XSDSimpleType]bool{}
+	for {
+		if ref != "" {
+			if _, mapped := g.typeMapping(ref); mapped {
+				return true
+			}
+			simpleType, _ = g.lookupType(ref).(*XSDSimpleType)
+		}
+		if simpleType == nil || seen[simpleType] {
+			return !runtimeStructTypes[g.toGoType(ref)]
+		}
+		seen[simpleType] = true
+
+		switch {
+		case simpleType.Union != nil:
+			return false
+		case simpleType.List != nil:
+			return true
+		}
+		ref = simpleType.Restriction.Base
+	}
+}
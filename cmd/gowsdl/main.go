@@ -8,6 +8,14 @@ Gowsdl generates Go code from a WSDL file.
 This project is originally intended to generate Go clients for WS-* services.
 
 Usage: gowsdl [options] myservice.wsdl
+  -choice string
+        How xs:choice groups are generated: fields, struct or interface (default "fields")
+  -exact-numbers
+        Maps xs:decimal to XSDDecimal and xs:integer to *big.Int, keeping every digit
+  -inline-types
+        Generates anonymous complex types as nested struct types instead of named types
+  -map value
+        Maps a type to an existing Go type, as {namespace}local=import/path.Type or xs:local=import/path.Type; may be repeated
   -o string
         File where the generated code will be saved (default "myservice.go")
   -p string
@@ -20,10 +28,12 @@ Supports only Document/Literal wrapped services, which are WS-I (http://ws-i.org
 
 Attempts to generate idiomatic Go code as much as possible.
 
-Supports WSDL 1.1, XML Schema 1.0, SOAP 1.1.
+Supports WSDL 1.1, XML Schema 1.0, SOAP 1.1 and SOAP 1.2.
 
 Resolves external XML Schemas
 
+Resolves WSDL imports, merging every imported document into one service definition.
+
 Supports providing WSDL HTTP URL as well as a local WSDL file.
 
 Not supported
@@ -34,10 +44,6 @@ TODO
 
 Add support for filters to allow the user to change the generated code.
 
-If WSDL file is local, resolve external XML schemas locally too instead of failing due to not having a URL to download them from.
-
-Resolve XSD element references.
-
 Support for generating namespaces.
 
 Make code generation agnostic so generating code to other programming languages is feasible through plugins.
@@ -53,6 +59,7 @@ import (
 	"go/format"
 	"log"
 	"os"
+	"strings"
 
 	gen "github.com/oshapeman/gowsdl"
 )
@@ -67,8 +74,26 @@ var vers = flag.Bool("v", false, "Shows gowsdl version")
 var pkg = flag.String("p", "myservice", "Package under which code will be generated")
 var outFile = flag.String("o", "myservice.go", "File where the generated code will be saved")
 var insecure = flag.Bool("i", false, "Skips TLS Verification")
+var choice = flag.String("choice", "fields", "How xs:choice groups are generated: fields, struct or interface")
+var exactNumbers = flag.Bool("exact-numbers", false, "Maps xs:decimal to XSDDecimal and xs:integer to *big.Int, keeping every digit")
+var inlineTypes = flag.Bool("inline-types", false, "Generates anonymous complex types as nested struct types instead of named types")
+var typeMappings mappingFlags
+
+// mappingFlags collects the type mappings given with -map.
+type mappingFlags []string
+
+func (m *mappingFlags) String() string {
+	return strings.Join(*m, ",")
+}
+
+func (m *mappingFlags) Set(value string) error {
+	*m = append(*m, value)
+	return nil
+}
 
 func init() {
+	flag.Var(&typeMappings, "map", "Maps a type to an existing Go type, as {namespace}local=import/path.Type or xs:local=import/path.Type; may be repeated")
+
 	log.SetFlags(0)
 	log.SetOutput(os.Stdout)
 	log.SetPrefix("🍀  ")
@@ -99,8 +124,22 @@ func main() {
 		log.Fatalln("Output file cannot be the same WSDL file")
 	}
 
+	choiceStyle, err := gen.ParseChoiceStyle(*choice)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	options := []gen.Option{gen.WithChoiceStyle(choiceStyle), gen.WithExactNumbers(*exactNumbers), gen.WithInlineTypes(*inlineTypes)}
+	for _, spec := range typeMappings {
+		name, mapping, err := gen.ParseTypeMapping(spec)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		options = append(options, gen.WithTypeMapping(name, mapping))
+	}
+
 	// load wsdl
-	gowsdl, err := gen.NewGoWSDL(wsdlPath, *pkg, *insecure)
+	gowsdl, err := gen.NewGoWSDL(wsdlPath, *pkg, *insecure, options...)
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -0,0 +1,134 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import "log"
+
+// fieldDefault is a value the setDefaults method generated for a struct
+// sets a field to: the default or fixed value of an element or attribute,
+// or the defaults of the embedded base type when Base is set.
+type fieldDefault struct {
+	Field string
+	Value string
+	// Go type of the embedded base, which is allocated when nil.
+	Base string
+}
+
+// defaultValue returns the value an element or attribute takes when
+// missing, the fixed value taking precedence over the default.
+func defaultValue(defaultAttr, fixed string) (string, bool) {
+	if fixed != "" {
+		return fixed, true
+	}
+	return defaultAttr, defaultAttr != ""
+}
+
+// hasSimpleValue tells whether the field generated for an element holds a
+// value of a named simple type, which default and fixed values apply to.
+func (g *GoWSDL) hasSimpleValue(el *XSDElement) bool {
+	if el.Type == "" || g.substitutionOf(el) != nil {
+		return false
+	}
+	_, complex := g.lookupType(el.Type).(*XSDComplexType)
+	return !complex
+}
+
+// defaults returns the values set by the setDefaults method generated for a
+// complex type, following the fields generated by ComplexTypeBody. Types
+// without any get no such method.
+func (g *GoWSDL) defaults(complexType *XSDComplexType) []*fieldDefault {
+	return g.complexTypeDefaults(complexType, map[*XSDComplexType]bool{})
+}
+
+func (g *GoWSDL) complexTypeDefaults(complexType *XSDComplexType, seen map[*XSDComplexType]bool) []*fieldDefault {
+	if seen[complexType] {
+		return nil
+	}
+	seen[complexType] = true
+	complexType = g.restated(complexType)
+
+	var defaults []*fieldDefault
+
+	if extension := complexType.ComplexContent.Extension; extension.Base != "" {
+		if base, ok := g.lookupType(extension.Base).(*XSDComplexType); ok && !g.isMapped(base) {
+			if len(g.complexTypeDefaults(base, seen)) > 0 {
+				goType := g.toGoType(extension.Base)
+				defaults = append(defaults, &fieldDefault{Field: embeddedName(goType), Base: valueType(goType)})
+			}
+		}
+		defaults = g.particleDefaults(defaults, extension.Content())
+		return g.attributeDefaults(defaults, extension.Attributes, extension.AttributeGroups)
+	}
+
+	if extension := complexType.SimpleContent.Extension; extension.Base != "" {
+		return g.attributeDefaults(defaults, extension.Attributes, extension.AttributeGroups)
+	}
+
+	defaults = g.particleDefaults(defaults, complexType.Content())
+	return g.attributeDefaults(defaults, complexType.Attributes, complexType.AttributeGroups)
+}
+
+// Appends the defaults of the elements of a particle generated as fields of
+// the enclosing struct. Elements that may occur more than once, or that are
+// alternatives of a choice, are left empty as setting them would add
+// occurrences.
+func (g *GoWSDL) particleDefaults(defaults []*fieldDefault, p *XSDParticle) []*fieldDefault {
+	if p == nil || g.groupParticles[p] != nil || isRepeated(p.MaxOccurs) {
+		return defaults
+	}
+
+	switch p.Kind {
+	case "element":
+		el := p.Element
+		decl, name := el, el.Name
+		if el.Ref != "" {
+			if decl = g.lookupElement(el.Ref); decl == nil {
+				return defaults
+			}
+			name = decl.Name
+		}
+
+		value, ok := defaultValue(decl.Default, decl.Fixed)
+		if !ok {
+			return defaults
+		}
+		if !g.hasSimpleValue(decl) {
+			log.Printf("[WARN] element %s doesn't have a named simple type, its default value won't be set", name)
+			return defaults
+		}
+		return append(defaults, &fieldDefault{Field: fieldName(name), Value: value})
+	case "group":
+		if group := g.lookupGroup(p.Group); group != nil {
+			defaults = g.particleDefaults(defaults, group.Content())
+		}
+	case "choice":
+		if len(p.Particles) == 1 {
+			defaults = g.particleDefaults(defaults, p.Particles[0])
+		}
+	case "sequence", "all":
+		for _, child := range p.Particles {
+			defaults = g.particleDefaults(defaults, child)
+		}
+	}
+
+	return defaults
+}
+
+// Appends the defaults of attributes.
+func (g *GoWSDL) attributeDefaults(defaults []*fieldDefault, attributes []*XSDAttribute, attributeGroups []*XSDAttributeGroup) []*fieldDefault {
+	for _, attribute := range g.attributeFields(attributes) {
+		if value, ok := defaultValue(attribute.Default, attribute.Fixed); ok {
+			defaults = append(defaults, &fieldDefault{Field: attribute.Field, Value: value})
+		}
+	}
+
+	for _, attributeGroup := range attributeGroups {
+		if resolved := g.lookupAttributeGroup(attributeGroup); resolved != nil {
+			defaults = g.attributeDefaults(defaults, resolved.Attributes, resolved.AttributeGroups)
+		}
+	}
+
+	return defaults
+}
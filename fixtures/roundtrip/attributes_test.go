@@ -0,0 +1,27 @@
+//go:build roundtrip
+
+package gen
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestRequiredAttributes(t *testing.T) {
+	out, err := xml.Marshal(&Document{Body: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []string{`:id=""`, `pages="0"`} {
+		if !strings.Contains(string(out), e) {
+			t.Errorf("unset required attributes should be encoded, %s not in %s", e, out)
+		}
+	}
+	if strings.Contains(string(out), "title=") {
+		t.Errorf("unset optional attributes should be left out: %s", out)
+	}
+	if err := (&Document{Body: "b"}).Validate(); err != nil {
+		t.Errorf("encoded required attributes aren't missing: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+//go:build roundtrip
+
+package gen
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+const order = `<Order xmlns="urn:example:orders">` +
+	`<Id>1</Id><Iban>DE00</Iban><Bic>ABCDEF</Bic>` +
+	`<Sku>a</Sku><Quantity>1</Quantity><Discount>2.5</Discount><Coupon>c</Coupon>` +
+	`<Sku>b</Sku><Quantity>2</Quantity>` +
+	`<Text>t</Text><Html>&lt;b/&gt;</Html>` +
+	`</Order>`
+
+func TestChoiceInterface(t *testing.T) {
+	var o Order
+	err := xml.Unmarshal([]byte(order), &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sequence, ok := o.Choice.(*OrderChoiceSequence)
+	if !ok || sequence.Iban != "DE00" || sequence.Bic != "ABCDEF" {
+		t.Fatalf("unexpected choice: %#v", o.Choice)
+	}
+	if len(o.Sequence) != 2 || len(o.Note) != 2 {
+		t.Errorf("unexpected content: %+v", o)
+	}
+
+	out, err := xml.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != order {
+		t.Errorf("expected\n%s\ngot\n%s", order, out)
+	}
+
+	o.Choice = &OrderChoiceCard{Card: "9"}
+	out, err = xml.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var o2 Order
+	err = xml.Unmarshal(out, &o2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if card, ok := o2.Choice.(*OrderChoiceCard); !ok || card.Card != "9" {
+		t.Errorf("unexpected choice: %#v", o2.Choice)
+	}
+
+	err = xml.Unmarshal([]byte(`<Order xmlns="urn:example:orders"><Card>9</Card><Iban>x</Iban></Order>`), &o2)
+	if err == nil {
+		t.Error("two alternatives of a choice should not unmarshal")
+	}
+
+	o.Choice = nil
+	err = o.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Card|Iban|Bic: required element is missing") {
+		t.Errorf("a missing choice should be located by its elements, got %v", err)
+	}
+}
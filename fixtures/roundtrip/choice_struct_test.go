@@ -0,0 +1,56 @@
+//go:build roundtrip
+
+package gen
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+const order = `<Order xmlns="urn:example:orders">` +
+	`<Id>1</Id><Iban>DE00</Iban><Bic>ABCDEF</Bic>` +
+	`<Sku>a</Sku><Quantity>1</Quantity><Discount>2.5</Discount><Coupon>c</Coupon>` +
+	`<Sku>b</Sku><Quantity>2</Quantity>` +
+	`<Text>t</Text><Html>&lt;b/&gt;</Html>` +
+	`</Order>`
+
+func TestChoiceStruct(t *testing.T) {
+	var o Order
+	err := xml.Unmarshal([]byte(order), &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if o.Choice.Card != nil || o.Choice.Sequence == nil || o.Choice.Sequence.Iban != "DE00" || o.Choice.Sequence.Bic != "ABCDEF" {
+		t.Fatalf("unexpected choice: %+v", o.Choice)
+	}
+
+	err = o.Choice.Validate()
+	if err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+
+	out, err := xml.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != order {
+		t.Errorf("expected\n%s\ngot\n%s", order, out)
+	}
+
+	card := "4111"
+	o.Choice.Card = &card
+	err = o.Choice.Validate()
+	if err == nil {
+		t.Error("two alternatives of a choice should not validate")
+	}
+
+	var o2 Order
+	err = xml.Unmarshal([]byte(`<Order xmlns="urn:example:orders"><Id>1</Id><Card>9</Card></Order>`), &o2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o2.Choice.Card == nil || *o2.Choice.Card != "9" || o2.Choice.Sequence != nil {
+		t.Errorf("unexpected choice: %+v", o2.Choice)
+	}
+}
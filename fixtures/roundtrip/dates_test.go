@@ -0,0 +1,119 @@
+//go:build roundtrip
+
+package gen
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestDateLexicalForms(t *testing.T) {
+	for _, c := range []struct {
+		in, out string
+		v       interface {
+			MarshalText() ([]byte, error)
+			UnmarshalText([]byte) error
+		}
+	}{
+		{"2024-01-02", "", &XSDDate{}},
+		{"2024-01-02Z", "", &XSDDate{}},
+		{"2024-01-02+01:00", "", &XSDDate{}},
+		{"-0044-03-15-05:30", "", &XSDDate{}},
+		{"12024-01-02", "", &XSDDate{}},
+		{"2024-02-29", "", &XSDDate{}},
+		{"2024-01-02T03:04:05", "", &XSDDateTime{}},
+		{"2024-01-02T03:04:05.120Z", "2024-01-02T03:04:05.12Z", &XSDDateTime{}},
+		{"2024-01-02T03:04:05+14:00", "", &XSDDateTime{}},
+		{"2024-12-31T24:00:00", "2025-01-01T00:00:00", &XSDDateTime{}},
+		{"13:20:00", "", &XSDTime{}},
+		{"13:20:00.5-05:00", "", &XSDTime{}},
+		{"24:00:00", "00:00:00", &XSDTime{}},
+		{"-P1Y2M3DT4H5M6.5S", "", &XSDDuration{}},
+		{"--02-29", "", &XSDGMonthDay{}},
+		{"---31-05:30", "", &XSDGDay{}},
+	} {
+		err := c.v.UnmarshalText([]byte(c.in))
+		if err != nil {
+			t.Errorf("%s: %v", c.in, err)
+			continue
+		}
+
+		out, err := c.v.MarshalText()
+		want := c.out
+		if want == "" {
+			want = c.in
+		}
+		if err != nil || string(out) != want {
+			t.Errorf("%s: expected %s, got %s %v", c.in, want, out, err)
+		}
+	}
+
+	for _, invalid := range []string{"2023-02-29T00:00:00", "2024-1-02T00:00:00", "02024-01-01T00:00:00", "2024-01-02T", "2024-01-02T25:00:00", "2024-01-02T24:00:01", "2024-01-02T00:00:00+15:00", "2024-01-02T03:04:05+0100"} {
+		var d XSDDateTime
+		if d.UnmarshalText([]byte(invalid)) == nil {
+			t.Errorf("dateTime %s should not unmarshal", invalid)
+		}
+	}
+
+	var d XSDDateTime
+	err := d.UnmarshalText([]byte("2024-01-02T03:04:05+01:00"))
+	if err != nil || !d.Time.Equal(time.Date(2024, 1, 2, 2, 4, 5, 0, time.UTC)) || !d.HasTimezone {
+		t.Errorf("unexpected dateTime: %v %v", d, err)
+	}
+
+	summer := time.Date(2024, 7, 1, 10, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	out, _ := NewXSDDateTime(summer).MarshalText()
+	if string(out) != "2024-07-01T10:00:00+02:00" {
+		t.Errorf("unexpected dateTime: %s", out)
+	}
+	out, _ = NewXSDTime(summer).MarshalText()
+	if string(out) != "10:00:00+02:00" {
+		t.Errorf("unexpected time: %s", out)
+	}
+	out, _ = NewXSDDate(summer).MarshalText()
+	if string(out) != "2024-07-01+02:00" {
+		t.Errorf("unexpected date: %s", out)
+	}
+}
+
+const event = `<Event xmlns="urn:example:calendar">` +
+	`<On>2024-01-02+01:00</On><Start>2024-03-04T05:06:07.25</Start><At>24:00:00Z</At><Length>-P1Y2M3DT4H5M6.5S</Length>` +
+	`<Year>-0044</Year><YearMonth>2024-02+01:00</YearMonth><Month>--03Z</Month><MonthDay>--02-29</MonthDay><DayOfMonth>---31-05:30</DayOfMonth>` +
+	`</Event>`
+
+func TestDateElements(t *testing.T) {
+	var e Event
+	err := xml.Unmarshal([]byte(event), &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e.On.Time.Day() != 2 || !e.On.HasTimezone || e.Start.Time.Nanosecond() != 250000000 || e.At.Time.Hour() != 0 ||
+		!e.Length.Negative || e.Length.Years != 1 || e.Year.Year != -44 || e.YearMonth.Month != 2 ||
+		e.MonthDay.Day != 29 || e.DayOfMonth.Day != 31 {
+		t.Errorf("unexpected dates: %+v", e)
+	}
+
+	out, err := xml.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var e2 Event
+	err = xml.Unmarshal(out, &e2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pair := range [][2]interface{ MarshalText() ([]byte, error) }{
+		{e.On, e2.On}, {e.Start, e2.Start}, {e.At, e2.At}, {e.Length, e2.Length}, {e.Year, e2.Year},
+		{e.YearMonth, e2.YearMonth}, {e.Month, e2.Month}, {e.MonthDay, e2.MonthDay}, {e.DayOfMonth, e2.DayOfMonth},
+	} {
+		first, _ := pair[0].MarshalText()
+		second, _ := pair[1].MarshalText()
+		if string(first) != string(second) {
+			t.Errorf("%s was not kept by\n%s", first, out)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+//go:build roundtrip
+
+package gen
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const paint = `<Paint xmlns="urn:example:paint" colors=" red  green ">` +
+	"<Sizes>1 2\n\t3</Sizes><Weights>1.5 2</Weights>" +
+	`<Size>12</Size><Size> +7 </Size><Size>red</Size>` +
+	`</Paint>`
+
+func TestListsAndUnions(t *testing.T) {
+	var p Paint
+	err := xml.Unmarshal([]byte(paint), &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*p.Sizes) != 3 || (*p.Sizes)[2] != 3 || (*p.Weights)[0] != 1.5 {
+		t.Errorf("unexpected lists: %v %v", *p.Sizes, *p.Weights)
+	}
+	if len(*p.Colors) != 2 || (*p.Colors)[1] != ColorGreen {
+		t.Errorf("unexpected colors: %v", *p.Colors)
+	}
+	if v, ok := p.Size[0].Value.(int32); !ok || v != 12 {
+		t.Errorf("expected the int member, got %#v", p.Size[0])
+	}
+	if v, ok := p.Size[1].Value.(int32); !ok || v != 7 || p.Size[1].Lexical != " +7 " {
+		t.Errorf("expected the int member and its lexical form, got %#v", p.Size[1])
+	}
+	if v, ok := p.Size[2].Value.(Color); !ok || v != ColorRed {
+		t.Errorf("expected the Color member, got %#v", p.Size[2])
+	}
+
+	out, err := xml.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []string{`colors="red green"`, `>1 2 3</Sizes>`, `>1.5 2</Weights>`, `> +7 </Size>`} {
+		if !strings.Contains(string(out), e) {
+			t.Errorf("expected %s in\n%s", e, out)
+		}
+	}
+
+	var p2 Paint
+	err = xml.Unmarshal(out, &p2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p, p2) {
+		t.Errorf("the lists and unions were not kept by\n%s", out)
+	}
+
+	out, err = xml.Marshal(SizeOrColor{Value: int32(5)})
+	if err != nil || string(out) != "<SizeOrColor>5</SizeOrColor>" {
+		t.Errorf("unexpected union: %s %v", out, err)
+	}
+
+	var u SizeOrColor
+	err = xml.Unmarshal([]byte(`<S>true</S>`), &u)
+	if err != nil || u.Value != true {
+		t.Errorf("expected the boolean member, got %#v %v", u, err)
+	}
+
+	err = xml.Unmarshal([]byte(`<S>blue</S>`), &u)
+	if err != nil || u.Value != nil || u.Validate() == nil {
+		t.Errorf("a value of no member should be kept and fail validation, got %#v %v", u, err)
+	}
+
+	err = xml.Unmarshal([]byte(`<Sizes>1 x</Sizes>`), new(Sizes))
+	if err == nil {
+		t.Error("an invalid list item should not unmarshal")
+	}
+}
@@ -0,0 +1,86 @@
+//go:build roundtrip
+
+package gen
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const document = `<GetDocumentResponse xmlns="urn:example:documents"><Title>T</Title>` +
+	`<Description lang="en">Hello <b>bold</b> and <i>it</i>, see <link href="http://example.com">here</link>!</Description>` +
+	`<Summary>Sum <Author>me</Author> end</Summary>` +
+	`<Comment>hey <Mention>bob</Mention> and <Mention>al</Mention>.</Comment>` +
+	`</GetDocumentResponse>`
+
+// The generated types qualify every element, which only adds redundant
+// namespace declarations.
+func withoutNamespaces(s string) string {
+	return strings.ReplaceAll(s, ` xmlns="urn:example:documents"`, "")
+}
+
+func TestMixedContent(t *testing.T) {
+	var r GetDocumentResponse
+	err := xml.Unmarshal([]byte(document), &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text := r.Description.Content.Text(); text != "Hello  and , see !" {
+		t.Errorf("unexpected description text %q", text)
+	}
+	if len(r.Description.Choice) != 3 {
+		t.Errorf("expected 3 children, got %+v", r.Description.Choice)
+	}
+	summary, ok := r.Summary.AnySummary.(*Summary)
+	if !ok || summary.Author == nil || *summary.Author != "me" || summary.Content.Text() != "Sum  end" {
+		t.Errorf("unexpected summary: %#v", r.Summary.AnySummary)
+	}
+	if len(r.Comment) != 1 || r.Comment[0].Content.Text() != "hey  and ." || len(r.Comment[0].Mention) != 2 {
+		t.Errorf("unexpected comment: %+v", r.Comment)
+	}
+
+	out, err := xml.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withoutNamespaces(string(out)) != withoutNamespaces(document) {
+		t.Errorf("expected\n%s\ngot\n%s", document, out)
+	}
+
+	var r2 GetDocumentResponse
+	err = xml.Unmarshal(out, &r2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r, r2) {
+		t.Errorf("the mixed content was not kept by\n%s", out)
+	}
+}
+
+const derived = `<GetDocumentResponse xmlns="urn:example:documents" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:d="urn:example:documents"><Title>T</Title>` +
+	`<Summary xsi:type="d:Abstract">S1 <Author>a</Author> S2 <Keyword>k</Keyword> S3</Summary>` +
+	`</GetDocumentResponse>`
+
+func TestDerivedMixedContent(t *testing.T) {
+	var r GetDocumentResponse
+	err := xml.Unmarshal([]byte(derived), &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	abstract, ok := r.Summary.AnySummary.(*Abstract)
+	if !ok || abstract.Content.Text() != "S1  S2  S3" || len(abstract.Keyword) != 1 {
+		t.Fatalf("unexpected summary: %#v", r.Summary.AnySummary)
+	}
+
+	out, err := xml.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(withoutNamespaces(string(out)), `>S1 <Author>a</Author> S2 <Keyword>k</Keyword> S3</Summary>`) {
+		t.Errorf("the text and children of the derived type were not kept by\n%s", out)
+	}
+}
@@ -0,0 +1,57 @@
+//go:build roundtrip
+
+package gen
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+const order = `<Order>` +
+	`<Id>1</Id><Iban>DE00</Iban><Bic>ABCDEF</Bic>` +
+	`<Sku>a</Sku><Quantity>1</Quantity><Discount>1.5</Discount><Coupon>c</Coupon>` +
+	`<Sku>b</Sku><Quantity>2</Quantity>` +
+	`<Text>t</Text><Html>h</Html>` +
+	`</Order>`
+
+func TestParticles(t *testing.T) {
+	var o Order
+	err := xml.Unmarshal([]byte(order), &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if o.Card != nil || o.Iban == nil || *o.Iban != "DE00" || o.Bic == nil || *o.Bic != "ABCDEF" {
+		t.Errorf("unexpected payment: %+v", o)
+	}
+	if len(o.Sequence) != 2 || o.Sequence[0].Sku != "a" || o.Sequence[1].Quantity != 2 {
+		t.Fatalf("unexpected sequence: %+v", o.Sequence)
+	}
+	choice := o.Sequence[0].Choice
+	if len(choice) != 2 || *choice[0].Discount != 1.5 || *choice[1].Coupon != "c" || len(o.Sequence[1].Choice) != 0 {
+		t.Errorf("unexpected choices: %+v", choice)
+	}
+	if len(o.Note) != 2 || *o.Note[0].Text != "t" || *o.Note[1].Html != "h" {
+		t.Errorf("unexpected notes: %+v", o.Note)
+	}
+
+	out, err := xml.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != order {
+		t.Errorf("expected\n%s\ngot\n%s", order, out)
+	}
+
+	err = o.Validate()
+	if err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+
+	o.Sequence = nil
+	err = o.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Sequence") {
+		t.Errorf("expected the missing sequence to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+//go:build roundtrip
+
+package gen
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+const animals = `<GetAnimalsResponse xmlns="urn:example:zoo" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:z="urn:example:zoo">` +
+	`<Animal xsi:type="z:Dog"><Name>rex</Name><Breed>lab</Breed></Animal>` +
+	`<Animal xsi:type="z:Puppy"><Name>pip</Name><Breed>pug</Breed><AgeInWeeks>3</AgeInWeeks></Animal>` +
+	`<Animal xsi:type="z:Cat"><Name>tom</Name><Lives>9</Lives></Animal>` +
+	`<Guard><Name>max</Name><Breed>boxer</Breed></Guard>` +
+	`</GetAnimalsResponse>`
+
+func TestPolymorphism(t *testing.T) {
+	var r GetAnimalsResponse
+	err := xml.Unmarshal([]byte(animals), &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Animal) != 3 {
+		t.Fatalf("expected 3 animals, got %d", len(r.Animal))
+	}
+	if dog, ok := r.Animal[0].AnyAnimal.(*Dog); !ok || dog.Name != "rex" || dog.Breed != "lab" {
+		t.Errorf("expected a dog, got %#v", r.Animal[0].AnyAnimal)
+	}
+	if puppy, ok := r.Animal[1].AnyAnimal.(*Puppy); !ok || puppy.Name != "pip" || puppy.AgeInWeeks != 3 {
+		t.Errorf("expected a puppy, got %#v", r.Animal[1].AnyAnimal)
+	}
+	if cat, ok := r.Animal[2].AnyAnimal.(*Cat); !ok || cat.Lives != 9 {
+		t.Errorf("expected a cat, got %#v", r.Animal[2].AnyAnimal)
+	}
+	if dog, ok := r.Guard.AnyDog.(*Dog); !ok || dog.Breed != "boxer" {
+		t.Errorf("elements without xsi:type should have their declared type, got %#v", r.Guard.AnyDog)
+	}
+
+	out, err := xml.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r2 GetAnimalsResponse
+	err = xml.Unmarshal(out, &r2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r, r2) {
+		t.Errorf("the derived types were not kept by\n%s", out)
+	}
+
+	// Values built in Go are marshalled with the xsi:type of their type.
+	r3 := GetAnimalsResponse{
+		Animal: []AnimalValue{{&Cat{Animal: &Animal{Name: "kit"}, Lives: 3}}},
+		Guard:  DogValue{&Puppy{Dog: &Dog{Animal: &Animal{Name: "bo"}}, AgeInWeeks: 2}},
+	}
+	out, err = xml.Marshal(r3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r4 GetAnimalsResponse
+	err = xml.Unmarshal(out, &r4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cat, ok := r4.Animal[0].AnyAnimal.(*Cat); !ok || cat.Name != "kit" || cat.Lives != 3 {
+		t.Errorf("expected a cat, got %#v", r4.Animal[0].AnyAnimal)
+	}
+	if puppy, ok := r4.Guard.AnyDog.(*Puppy); !ok || puppy.Name != "bo" || puppy.AgeInWeeks != 2 {
+		t.Errorf("expected a puppy, got %#v", r4.Guard.AnyDog)
+	}
+
+	err = r4.Validate()
+	if err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+//go:build roundtrip
+
+package gen
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+const drawing = `<Draw xmlns="urn:example:drawing">` +
+	`<Name>n</Name>` +
+	`<Circle><Color>red</Color><Radius>2</Radius></Circle>` +
+	`<Square><Side>3</Side></Square>` +
+	`<RoundedSquare><Side>1</Side><CornerRadius>1</CornerRadius></RoundedSquare>` +
+	`<Title>T</Title>` +
+	`</Draw>`
+
+func TestSubstitution(t *testing.T) {
+	var r Draw
+	err := xml.Unmarshal([]byte(drawing), &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Shape) != 3 {
+		t.Fatalf("expected 3 shapes, got %d", len(r.Shape))
+	}
+	if circle, ok := r.Shape[0].Value.(*CircleType); !ok || circle.Radius != 2 || circle.Color != "red" {
+		t.Errorf("expected a circle, got %#v", r.Shape[0].Value)
+	}
+	if square, ok := r.Shape[1].Value.(*SquareType); !ok || square.Side != 3 {
+		t.Errorf("expected a square, got %#v", r.Shape[1].Value)
+	}
+	if rounded, ok := r.Shape[2].Value.(*RoundedSquare); !ok || rounded.CornerRadius != 1 {
+		t.Errorf("expected a rounded square, got %#v", r.Shape[2].Value)
+	}
+	if r.Label.XMLName.Local != "Title" {
+		t.Errorf("expected the Title substitute of Label, got %v", r.Label.XMLName)
+	}
+
+	out, err := xml.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r2 Draw
+	err = xml.Unmarshal(out, &r2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r, r2) {
+		t.Errorf("the substitutes were not kept by\n%s", out)
+	}
+
+	// Values built in Go are marshalled under the element of their type.
+	r3 := Draw{Name: "x", Shape: ShapeSubstitutionList{{Value: &CircleType{ShapeType: &ShapeType{Color: "blue"}, Radius: 5}}}}
+	out, err = xml.Marshal(r3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r4 Draw
+	err = xml.Unmarshal(out, &r4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if circle, ok := r4.Shape[0].Value.(*CircleType); !ok || circle.Radius != 5 || circle.Color != "blue" {
+		t.Errorf("expected a circle, got %#v in\n%s", r4.Shape[0].Value, out)
+	}
+
+	err = r4.Validate()
+	if err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+//go:build roundtrip
+
+package gen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	open, pending := StatusOpen, Status("pending")
+	code, long, lower := ShortCode("AB"), ShortCode("ABCD"), ShortCode("ab")
+	percent := Percent(120)
+	tag := func(s string) *Tag { v := Tag(s); return &v }
+
+	o := &PlaceOrder{Status: &open, Item: []*Item{{Code: &code, Id: "1"}, {Code: &code}}}
+	if err := o.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	o = &PlaceOrder{Status: &pending, Item: []*Item{
+		{Code: &code, Id: "1"},
+		{Code: &long, Id: "2", Discount: &percent, Tag: []*Tag{tag(""), tag("toolong"), tag("ok")}},
+		{Code: &lower, Id: "3"},
+	}}
+	err := o.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, e := range []string{
+		`Item[2]/Code: length is 4, must be at most 3`,
+		`Item[2]/Discount: 120 is greater than 100`,
+		`Item[2]/Tag: 3 occurrences, at most 2 allowed`,
+		`Item[2]/Tag[1]: length is 0, must be at least 1`,
+		`Item[2]/Tag[2]: length is 7, must be at most 5`,
+		`Item[3]/Code: "ab" does not match pattern ^(?:[A-Z]{2,4})$`,
+		`@status: "pending" is not one of ["open" "closed"]`,
+	} {
+		if !strings.Contains(err.Error(), e) {
+			t.Errorf("validation errors should contain %q, got %v", e, err)
+		}
+	}
+
+	err = (&PlaceOrder{}).Validate()
+	if err == nil || err.Error() != "Item: required element is missing" {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
+
+func TestFacets(t *testing.T) {
+	tests := []struct {
+		value interface{ Validate() error }
+		err   string
+	}{
+		{Code("ABC"), ""},
+		{Code("123"), ""},
+		{Code("12"), `does not match pattern ^(?:[A-Z]{2,4})$ | ^(?:[0-9]{3})$`},
+		{Price(999.99), ""},
+		{Price(0), "0 is not greater than 0"},
+		{Price(1000), "1000 is not less than 1000"},
+		{Price(1.234), "1.234 has 3 fraction digits, at most 2 allowed"},
+		{Price(12345.6), "12345.6 has 6 digits, at most 5 allowed"},
+		{Size("  extra \n large "), ""},
+		{Size("large"), `"large" is not one of`},
+		{Even(3), ""},
+		{Stock(5), `invalid bound "1e3"`},
+	}
+	for _, test := range tests {
+		err := test.value.Validate()
+		if test.err == "" && err != nil || test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%#v: unexpected validation error %v, expected %q", test.value, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+//go:build roundtrip
+
+package gen
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+const register = `<Register xmlns="urn:example:registry" xmlns:x="urn:example:x" xmlns:v="urn:example:values" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+	`<Entry xsi:type="Service" x:color="red" plain="p"><Name>n</Name>` +
+	`<x:ext xmlns:y="urn:example:y" y:k="v" xsi:type="y:Q"><x:c>t</x:c><d xmlns="">u</d><Name>inherit</Name></x:ext>` +
+	`<Endpoint>http://example.com</Endpoint></Entry>` +
+	`<Properties extra="1" x:more="2"><Key>a</Key><v:val>1</v:val><Key>b</Key><raw xmlns="">2</raw></Properties>` +
+	`<Note><local xmlns=""></local><Text>t</Text><x:after></x:after></Note>` +
+	`</Register>`
+
+func TestWildcards(t *testing.T) {
+	var r Register
+	err := xml.Unmarshal([]byte(register), &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service, ok := r.Entry.AnyEntry.(*Service)
+	if !ok {
+		t.Fatalf("expected a service, got %#v", r.Entry.AnyEntry)
+	}
+	if service.Name != "n" || service.Endpoint != "http://example.com" || len(service.Any) != 1 || len(service.AnyAttr) != 2 {
+		t.Errorf("unexpected service: %+v %+v", service, service.Entry)
+	}
+	properties := r.Properties.Sequence
+	if len(properties) != 2 || properties[0].Key != "a" || properties[1].Any[0].XMLName.Local != "raw" || len(r.Properties.AnyAttr) != 2 {
+		t.Errorf("unexpected properties: %+v", r.Properties)
+	}
+
+	// plain is in no namespace, which the attribute wildcard of Entry doesn't
+	// allow.
+	err = r.Validate()
+	if err == nil || !strings.Contains(err.Error(), "plain") {
+		t.Errorf("expected the plain attribute to be reported, got %v", err)
+	}
+
+	out, err := xml.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r2 Register
+	err = xml.Unmarshal(out, &r2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out2, err := xml.Marshal(r2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != string(out2) {
+		t.Errorf("encoding is not stable:\n%s\n%s", out, out2)
+	}
+
+	// The content of the elements matched is encoded back with the
+	// namespaces it had.
+	ext := r2.Entry.AnyEntry.(*Service).Any[0]
+	raw, err := xml.Marshal(ext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content struct {
+		C    string `xml:"urn:example:x c"`
+		D    string `xml:" d"`
+		Name string `xml:"urn:example:registry Name"`
+		K    string `xml:"urn:example:y k,attr"`
+		Type string `xml:"http://www.w3.org/2001/XMLSchema-instance type,attr"`
+	}
+	err = xml.Unmarshal(raw, &content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.C != "t" || content.D != "u" || content.Name != "inherit" || content.K != "v" || content.Type != "y:Q" {
+		t.Errorf("unexpected content %+v of\n%s", content, raw)
+	}
+
+	bad := &Entry{Name: "x", Any: []AnyElement{{XMLName: xml.Name{Space: "urn:example:registry", Local: "Bad"}}}}
+	err = bad.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Any[1]") {
+		t.Errorf("expected the element in the target namespace to be reported, got %v", err)
+	}
+
+	var response RegisterResponse
+	err = xml.Unmarshal([]byte(`<RegisterResponse xmlns="urn:example:registry"><Accepted>true</Accepted><Receipt id="1">r</Receipt></RegisterResponse>`), &response)
+	if err != nil || len(response.Any) != 1 || response.Any[0].InnerXML != "r" {
+		t.Errorf("unexpected response: %+v %v", response, err)
+	}
+}
+
+// Each wildcard of Note keeps the elements it matched in its place.
+func TestWildcardOrder(t *testing.T) {
+	const note = `<Note xmlns="urn:example:registry"><local xmlns=""></local><Text>t</Text><after xmlns="urn:example:x"></after></Note>`
+
+	var n Note
+	err := xml.Unmarshal([]byte(note), &n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(n.Any) != 1 || n.Any[0].XMLName.Local != "local" || n.Text != "t" || len(n.Any2) != 1 || n.Any2[0].XMLName.Local != "after" {
+		t.Fatalf("unexpected note: %+v", n)
+	}
+
+	err = n.Validate()
+	if err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+
+	out, err := xml.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, text, after := strings.Index(string(out), "<local"), strings.Index(string(out), "<Text"), strings.Index(string(out), "<after")
+	if local < 0 || local > text || text > after {
+		t.Errorf("expected local, Text and after in order, got\n%s", out)
+	}
+
+	var n2 Note
+	err = xml.Unmarshal(out, &n2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(n2.Any) != 1 || len(n2.Any2) != 1 || n2.Any2[0].XMLName.Space != "urn:example:x" {
+		t.Errorf("the wildcards were not kept by\n%s", out)
+	}
+
+	// Each wildcard checks the namespaces it allows.
+	n2.Any, n2.Any2 = n2.Any2, n2.Any
+	err = n2.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Any[1]") || !strings.Contains(err.Error(), "Any2[1]") {
+		t.Errorf("expected both wildcards to be reported, got %v", err)
+	}
+}
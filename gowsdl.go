@@ -17,6 +17,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"text/template"
@@ -30,9 +31,35 @@ const maxRecursion uint8 = 20
 type GoWSDL struct {
 	file, pkg             string
 	ignoreTLS             bool
+	choiceStyle           ChoiceStyle
+	exactNumbers          bool
+	inlineTypes           bool
+	typeMappings          map[string]TypeMapping
+	mappingImports        []string
 	wsdl                  *WSDL
 	resolvedXSDExternals  map[string]bool
 	currentRecursionLevel uint8
+	loadedWSDLs           map[string]bool
+	wsdlDefinitions       map[string]wsdlDefinition
+	types                 map[string]interface{}
+	elements              map[string]*XSDElement
+	groups                map[string]*XSDGroup
+	attributeGroups       map[string]*XSDAttributeGroup
+	attributes            map[string]*XSDAttribute
+	groupTypes            []*groupType
+	groupParticles        map[*XSDParticle]*groupType
+	optionalElements      map[*XSDElement]bool
+	wildcardFields        map[*XSDParticle]string
+	polymorphicTypes      []*polymorphicType
+	polymorphicNames      map[string]*polymorphicType
+	substitutionGroups    []*substitutionGroup
+	substitutions         map[*XSDElement]*substitutionGroup
+	goNames               map[interface{}]string
+	anonymousTypes        map[interface{}]bool
+	restatedTypes         map[*XSDComplexType]*XSDComplexType
+	namespaces            map[interface{}]string
+	usedNames             map[string]bool
+	unresolved            []error
 }
 
 var cacheDir = filepath.Join(os.TempDir(), "gowsdl-cache")
@@ -75,7 +102,7 @@ func downloadFile(url string, ignoreTLS bool) ([]byte, error) {
 }
 
 // NewGoWSDL initializes WSDL generator.
-func NewGoWSDL(file, pkg string, ignoreTLS bool) (*GoWSDL, error) {
+func NewGoWSDL(file, pkg string, ignoreTLS bool, options ...Option) (*GoWSDL, error) {
 	file = strings.TrimSpace(file)
 	if file == "" {
 		return nil, errors.New("WSDL file is required to generate Go proxy")
@@ -86,11 +113,18 @@ func NewGoWSDL(file, pkg string, ignoreTLS bool) (*GoWSDL, error) {
 		pkg = "myservice"
 	}
 
-	return &GoWSDL{
+	g := &GoWSDL{
 		file:      file,
 		pkg:       pkg,
 		ignoreTLS: ignoreTLS,
-	}, nil
+	}
+
+	for _, option := range options {
+		option(g)
+	}
+	g.aliasImports()
+
+	return g, nil
 }
 
 // Start initiaties the code generation process by starting two goroutines: one
@@ -129,12 +163,12 @@ func (g *GoWSDL) Start() (map[string][]byte, error) {
 
 	wg.Wait()
 
-	gocode["header"], err = g.genHeader()
+	gocode["soap"], err = g.genSOAPClient(gocode["types"], gocode["operations"])
 	if err != nil {
 		log.Println(err)
 	}
 
-	gocode["soap"], err = g.genSOAPClient()
+	gocode["header"], err = g.genHeader(gocode["types"], gocode["operations"], gocode["soap"])
 	if err != nil {
 		log.Println(err)
 	}
@@ -143,33 +177,113 @@ func (g *GoWSDL) Start() (map[string][]byte, error) {
 }
 
 func (g *GoWSDL) unmarshal() error {
-	var data []byte
+	g.wsdl = nil
+	g.loadedWSDLs = make(map[string]bool)
+	g.wsdlDefinitions = make(map[string]wsdlDefinition)
+	g.resolvedXSDExternals = nil
+	g.unresolved = nil
+
+	err := g.loadWSDL(g.file)
+	if err != nil {
+		return err
+	}
+
+	g.indexDefinitions()
+	g.checkReferences()
+
+	for _, err := range g.unresolved {
+		log.Printf("[WARN] %v", err)
+	}
+
+	return nil
+}
+
+// Fetches a document either from the local filesystem or from an URL.
+func (g *GoWSDL) fetchFile(location string) ([]byte, error) {
+	parsedURL, err := url.Parse(location)
+	if err != nil || parsedURL.Scheme == "" {
+		//log.Println("Reading", "file", location)
+		return ioutil.ReadFile(location)
+	}
+
+	log.Println("Downloading", "file", location)
+	return downloadFile(location, g.ignoreTLS)
+}
+
+// Resolves a location referenced by a document against the location of the
+// document itself, which can be either a local file or an URL.
+func resolveLocation(base, location string) string {
+	ref, err := url.Parse(location)
+	if err != nil || ref.IsAbs() || filepath.IsAbs(location) {
+		return location
+	}
+
+	baseURL, err := url.Parse(base)
+	if err == nil && baseURL.IsAbs() {
+		return baseURL.ResolveReference(ref).String()
+	}
+
+	return filepath.Join(filepath.Dir(base), filepath.FromSlash(location))
+}
+
+// Loads a WSDL document and, recursively, every document it imports, merging
+// all of them into g.wsdl.
+func (g *GoWSDL) loadWSDL(location string) error {
+	if g.loadedWSDLs[location] {
+		return nil
+	}
+	g.loadedWSDLs[location] = true
+
+	data, err := g.fetchFile(location)
+	if err != nil {
+		return err
+	}
 
-	parsedURL, err := url.Parse(g.file)
-	if parsedURL.Scheme == "" {
-		//log.Println("Reading", "file", g.file)
+	root, err := rootElement(data)
+	if err != nil {
+		return fmt.Errorf("%s: %v", location, err)
+	}
 
-		data, err = ioutil.ReadFile(g.file)
+	// Some WSDLs import their XML schemas through wsdl:import instead of
+	// xsd:import.
+	if root.Local == "schema" && g.wsdl != nil {
+		schema := &XSDSchema{location: location}
+		err = g.unmarshalDocument(data, schema, location)
 		if err != nil {
 			return err
 		}
-	} else {
-		log.Println("Downloading", "file", g.file)
 
-		data, err = downloadFile(g.file, g.ignoreTLS)
+		err = g.mergeSchema(schema, location)
 		if err != nil {
 			return err
 		}
+		return g.resolveXSDExternals(schema, location)
+	}
+
+	doc := new(WSDL)
+	err = g.unmarshalDocument(data, doc, location)
+	if err != nil {
+		return err
 	}
 
-	g.wsdl = new(WSDL)
-	err = xml.Unmarshal(data, g.wsdl)
+	err = g.mergeWSDL(doc, location)
 	if err != nil {
 		return err
 	}
 
-	for _, schema := range g.wsdl.Types.Schemas {
-		err = g.resolveXSDExternals(schema, parsedURL)
+	for _, schema := range doc.Types.Schemas {
+		err = g.resolveXSDExternals(schema, location)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, imp := range doc.Imports {
+		if imp.Location == "" {
+			continue
+		}
+
+		err = g.loadWSDL(resolveLocation(location, imp.Location))
 		if err != nil {
 			return err
 		}
@@ -178,56 +292,162 @@ func (g *GoWSDL) unmarshal() error {
 	return nil
 }
 
-func (g *GoWSDL) getSchema(schemaLocation string, url *url.URL) error {
-	_, schemaFile := filepath.Split(schemaLocation)
+// Returns the name of the root element of a XML document.
+func rootElement(data []byte) (xml.Name, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.Name{}, err
+		}
 
-	data, err := ioutil.ReadFile(schemaFile)
-	if err != nil {
+		if se, ok := token.(xml.StartElement); ok {
+			return se.Name, nil
+		}
+	}
+}
+
+// wsdlDefinition records where a message, port type, binding, service or
+// global schema definition was first defined, so it can be told apart from
+// redefinitions in imported documents.
+type wsdlDefinition struct {
+	location   string
+	definition interface{}
+}
 
-		location, err := url.Parse(schemaLocation)
+// Merges the definitions of a WSDL document into g.wsdl. The first document
+// merged is the one given to the generator and keeps its name, namespace and
+// documentation.
+func (g *GoWSDL) mergeWSDL(doc *WSDL, location string) error {
+	if g.wsdl == nil {
+		g.wsdl = &WSDL{
+			Name:            doc.Name,
+			TargetNamespace: doc.TargetNamespace,
+			Imports:         doc.Imports,
+			Doc:             doc.Doc,
+			Types:           WSDLType{Doc: doc.Types.Doc},
+		}
+	}
+
+	for _, schema := range doc.Types.Schemas {
+		err := g.mergeSchema(schema, location)
 		if err != nil {
 			return err
 		}
+	}
 
-		schemaLocation = location.String()
+	for _, msg := range doc.Messages {
+		msg.namespace = doc.TargetNamespace
+		ok, err := g.defineWSDL("message", doc.TargetNamespace, msg.Name, msg, location)
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.wsdl.Messages = append(g.wsdl.Messages, msg)
+		}
+	}
 
-		if !location.IsAbs() {
-			if !url.IsAbs() {
-				return fmt.Errorf("Unable to resolve external schema %s through WSDL URL %s", schemaLocation, url)
-			}
-			schemaLocation = url.Scheme + "://" + url.Host + schemaLocation
+	for _, portType := range doc.PortTypes {
+		portType.namespace = doc.TargetNamespace
+		ok, err := g.defineWSDL("portType", doc.TargetNamespace, portType.Name, portType, location)
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.wsdl.PortTypes = append(g.wsdl.PortTypes, portType)
 		}
+	}
 
-		log.Println("Downloading external schema", "location", schemaLocation)
+	for _, binding := range doc.Binding {
+		binding.namespace = doc.TargetNamespace
+		ok, err := g.defineWSDL("binding", doc.TargetNamespace, binding.Name, binding, location)
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.wsdl.Binding = append(g.wsdl.Binding, binding)
+		}
+	}
 
-		data, err = downloadFile(schemaLocation, g.ignoreTLS)
+	for _, service := range doc.Service {
+		service.namespace = doc.TargetNamespace
+		ok, err := g.defineWSDL("service", doc.TargetNamespace, service.Name, service, location)
 		if err != nil {
 			return err
 		}
+		if ok {
+			g.wsdl.Service = append(g.wsdl.Service, service)
+		}
+	}
+
+	return nil
+}
+
+// Registers a WSDL definition. It returns false if an identical definition
+// was already registered and an error if a different one was.
+func (g *GoWSDL) defineWSDL(kind, namespace, name string, definition interface{}, location string) (bool, error) {
+	key := kind + " " + qname(namespace, name)
+
+	previous, ok := g.wsdlDefinitions[key]
+	if !ok {
+		g.wsdlDefinitions[key] = wsdlDefinition{location: location, definition: definition}
+		return true, nil
+	}
+
+	if reflect.DeepEqual(previous.definition, definition) {
+		return false, nil
+	}
+
+	return false, fmt.Errorf("conflicting definitions of %s %q in %s and %s", kind, name, previous.location, location)
+}
+
+// Loads an external schema from its location, resolved against the
+// document referencing it. Schemas without target namespace take the one of
+// the schema including them.
+func (g *GoWSDL) getSchema(schemaLocation, base, namespace string) error {
+	location := resolveLocation(base, schemaLocation)
+	data, err := g.fetchFile(location)
+	if err != nil {
+		// Fall back to a copy of the schema in the working directory.
+		_, schemaFile := filepath.Split(schemaLocation)
+		var localErr error
+		data, localErr = ioutil.ReadFile(schemaFile)
+		if localErr != nil {
+			return fmt.Errorf("Unable to resolve external schema %s from %s: %v", schemaLocation, base, err)
+		}
+		location = schemaFile
 	}
-	newschema := new(XSDSchema)
+	newschema := &XSDSchema{location: location}
 
-	err = xml.Unmarshal(data, newschema)
+	err = g.unmarshalDocument(data, newschema, location)
 	if err != nil {
 		return err
 	}
 
+	if newschema.TargetNamespace == "" {
+		newschema.TargetNamespace = namespace
+	}
+
 	if len(newschema.Includes) > 0 || len(newschema.Imports) > 0 {
 		if maxRecursion > g.currentRecursionLevel {
 
 			g.currentRecursionLevel++
 
 			//log.Printf("Entering recursion %d\n", g.currentRecursionLevel)
-			g.resolveXSDExternals(newschema, url)
+			err = g.resolveXSDExternals(newschema, location)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
-	g.wsdl.Types.Schemas = append(g.wsdl.Types.Schemas, newschema)
-
-	return nil
+	return g.mergeSchema(newschema, location)
 }
 
-func (g *GoWSDL) resolveXSDExternals(schema *XSDSchema, url *url.URL) error {
+// Loads the schemas included and imported by a schema read from base. Each
+// is loaded once, as identified by its resolved location and the namespace
+// it is loaded into.
+func (g *GoWSDL) resolveXSDExternals(schema *XSDSchema, base string) error {
 	if len(schema.Includes) > 0 || len(schema.Imports) > 0 {
 		if g.resolvedXSDExternals == nil {
 			g.resolvedXSDExternals = make(map[string]bool, maxRecursion)
@@ -236,47 +456,169 @@ func (g *GoWSDL) resolveXSDExternals(schema *XSDSchema, url *url.URL) error {
 
 	var err error
 	for _, incl := range schema.Includes {
-		if g.resolvedXSDExternals[incl.SchemaLocation] {
+		key := qname(schema.TargetNamespace, resolveLocation(base, incl.SchemaLocation))
+		if g.resolvedXSDExternals[key] {
 			continue
 		}
+		g.resolvedXSDExternals[key] = true
 
-		err = g.getSchema(incl.SchemaLocation, url)
+		err = g.getSchema(incl.SchemaLocation, base, schema.TargetNamespace)
 		if err != nil {
 			return err
 		}
-
-		g.resolvedXSDExternals[incl.SchemaLocation] = true
 	}
 
 	for _, imp := range schema.Imports {
-		if g.resolvedXSDExternals[imp.SchemaLocation] {
+		if imp.SchemaLocation == "" {
+			continue
+		}
+
+		key := resolveLocation(base, imp.SchemaLocation)
+		if g.resolvedXSDExternals[key] {
 			continue
 		}
+		g.resolvedXSDExternals[key] = true
 
-		err = g.getSchema(imp.SchemaLocation, url)
+		err = g.getSchema(imp.SchemaLocation, base, "")
 		if err != nil {
 			return err
 		}
+	}
+
+	return nil
+}
+
+// Merges a schema read from location into g.wsdl. Global definitions
+// identical to ones already merged are dropped, so schemas inlined by
+// several imported documents are generated once, while different
+// definitions of the same name in different documents are an error.
+func (g *GoWSDL) mergeSchema(schema *XSDSchema, location string) error {
+	ns := schema.TargetNamespace
+
+	var err error
+	define := func(kind, name string, definition interface{}) bool {
+		if err != nil {
+			return false
+		}
+
+		key := "schema " + kind + " " + qname(ns, name)
+		previous, ok := g.wsdlDefinitions[key]
+		switch {
+		case !ok:
+			g.wsdlDefinitions[key] = wsdlDefinition{location: location, definition: definition}
+			return true
+		case reflect.DeepEqual(previous.definition, definition):
+			return false
+		case previous.location != location:
+			err = fmt.Errorf("conflicting definitions of %s %q in %s and %s", kind, name, previous.location, location)
+			return false
+		}
+		// Duplicates within a document are generated as before.
+		return true
+	}
 
-		g.resolvedXSDExternals[imp.SchemaLocation] = true
+	simpleTypes := schema.SimpleType[:0]
+	for _, simpleType := range schema.SimpleType {
+		if define("type", simpleType.Name, simpleType) {
+			simpleTypes = append(simpleTypes, simpleType)
+		}
+	}
+	schema.SimpleType = simpleTypes
 
+	complexTypes := schema.ComplexTypes[:0]
+	for _, complexType := range schema.ComplexTypes {
+		if define("type", complexType.Name, complexType) {
+			complexTypes = append(complexTypes, complexType)
+		}
 	}
+	schema.ComplexTypes = complexTypes
 
+	elements := schema.Elements[:0]
+	for _, element := range schema.Elements {
+		if define("element", element.Name, element) {
+			elements = append(elements, element)
+		}
+	}
+	schema.Elements = elements
+
+	attributes := schema.Attributes[:0]
+	for _, attribute := range schema.Attributes {
+		if define("attribute", attribute.Name, attribute) {
+			attributes = append(attributes, attribute)
+		}
+	}
+	schema.Attributes = attributes
+
+	groups := schema.Groups[:0]
+	for _, group := range schema.Groups {
+		if define("group", group.Name, group) {
+			groups = append(groups, group)
+		}
+	}
+	schema.Groups = groups
+
+	attributeGroups := schema.AttributeGroups[:0]
+	for _, attributeGroup := range schema.AttributeGroups {
+		if define("attribute group", attributeGroup.Name, attributeGroup) {
+			attributeGroups = append(attributeGroups, attributeGroup)
+		}
+	}
+	schema.AttributeGroups = attributeGroups
+
+	if err != nil {
+		return err
+	}
+
+	schema.location = location
+	g.wsdl.Types.Schemas = append(g.wsdl.Types.Schemas, schema)
 	return nil
 }
 
 func (g *GoWSDL) genTypes() ([]byte, error) {
 	funcMap := template.FuncMap{
-		"toGoType":             toGoType,
-		"stripns":              stripns,
-		"replaceReservedWords": replaceReservedWords,
-		"makePublic":           makePublic,
-		"comment":              comment,
+		"toGoType":               g.toGoType,
+		"goTypeName":             g.goTypeName,
+		"stripns":                stripns,
+		"replaceReservedWords":   replaceReservedWords,
+		"makePublic":             makePublic,
+		"comment":                comment,
+		"refElement":             g.lookupElement,
+		"elementGoType":          g.elementGoType,
+		"elementNamespace":       g.elementNamespace,
+		"refGroup":               g.lookupGroup,
+		"refAttributeGroup":      g.lookupAttributeGroup,
+		"repeated":               isRepeated,
+		"groupOf":                g.groupOf,
+		"groupTypes":             g.listGroupTypes,
+		"groupDecoderFields":     g.groupDecoderFields,
+		"pointer":                pointer,
+		"fieldType":              g.fieldType,
+		"polymorphicTypes":       g.listPolymorphicTypes,
+		"substitutionOf":         g.substitutionOf,
+		"substitutionGroups":     g.listSubstitutionGroups,
+		"listItemType":           g.listItemType,
+		"unionMemberTypes":       g.unionMemberTypes,
+		"valueType":              valueType,
+		"validations":            g.validations,
+		"groupValidations":       g.groupValidations,
+		"alternativeValidations": g.alternativeValidations,
+		"facets":                 g.facets,
+		"simpleBase":             g.simpleBase,
+		"textBase":               g.textBase,
+		"enumValue":              g.enumValue,
+		"mapped":                 g.isMapped,
+		"elementFieldType":       g.elementFieldType,
+		"optional":               g.isOptional,
+		"defaults":               g.defaults,
+		"attributeFields":        g.attributeFields,
+		"extended":               g.isExtended,
+		"wildcardField":          g.wildcardField,
+		"attributeWildcard":      g.attributeWildcard,
+		"catchesAttributes":      g.catchesAttributes,
+		"mixed":                  g.isMixed,
+		"restated":               g.restated,
 	}
 
-	//TODO resolve element refs in place.
-	//g.resolveElementsRefs()
-
 	data := new(bytes.Buffer)
 	tmpl := template.Must(template.New("types").Funcs(funcMap).Parse(typesTmpl))
 	err := tmpl.Execute(data, g.wsdl.Types)
@@ -289,13 +631,15 @@ func (g *GoWSDL) genTypes() ([]byte, error) {
 
 func (g *GoWSDL) genOperations() ([]byte, error) {
 	funcMap := template.FuncMap{
-		"toGoType":             toGoType,
+		"toGoType":             g.toGoType,
 		"stripns":              stripns,
 		"replaceReservedWords": replaceReservedWords,
 		"makePublic":           makePublic,
 		"findType":             g.findType,
+		"findElement":          g.findElement,
 		"findSOAPAction":       g.findSOAPAction,
 		"findServiceAddress":   g.findServiceAddress,
+		"isSOAP12":             g.isSOAP12,
 	}
 
 	data := new(bytes.Buffer)
@@ -308,9 +652,11 @@ func (g *GoWSDL) genOperations() ([]byte, error) {
 	return data.Bytes(), nil
 }
 
-func (g *GoWSDL) genHeader() ([]byte, error) {
+// genHeader generates the package clause and the imports of the code
+// generated as types, operations and SOAP client.
+func (g *GoWSDL) genHeader(code ...[]byte) ([]byte, error) {
 	funcMap := template.FuncMap{
-		"toGoType":             toGoType,
+		"toGoType":             g.toGoType,
 		"stripns":              stripns,
 		"replaceReservedWords": replaceReservedWords,
 		"makePublic":           makePublic,
@@ -318,9 +664,26 @@ func (g *GoWSDL) genHeader() ([]byte, error) {
 		"comment":              comment,
 	}
 
+	var mappings []TypeMapping
+	for _, name := range g.mappedTypes() {
+		if mapping := g.typeMappings[name]; mapping.ImportPath != "" {
+			mappings = append(mappings, mapping)
+		}
+	}
+
+	imports, err := usedImports(code...)
+	if err != nil {
+		return nil, err
+	}
+
 	data := new(bytes.Buffer)
 	tmpl := template.Must(template.New("header").Funcs(funcMap).Parse(headerTmpl))
-	err := tmpl.Execute(data, g.pkg)
+	err = tmpl.Execute(data, struct {
+		Package        string
+		Imports        []string
+		MappingImports []string
+		Mappings       []TypeMapping
+	}{g.pkg, imports, g.mappingImports, mappings})
 	if err != nil {
 		return nil, err
 	}
@@ -328,15 +691,31 @@ func (g *GoWSDL) genHeader() ([]byte, error) {
 	return data.Bytes(), nil
 }
 
-func (g *GoWSDL) genSOAPClient() ([]byte, error) {
+// genSOAPClient generates the SOAP client and the runtime the code
+// generated as types and operations uses.
+func (g *GoWSDL) genSOAPClient(code ...[]byte) ([]byte, error) {
+	used := make(map[string]bool)
+	for _, c := range code {
+		identifiers(used, c)
+	}
+
+	// Polymorphic types are only decoded as mixed content when some are
+	// mixed.
 	data := new(bytes.Buffer)
 	tmpl := template.Must(template.New("soapclient").Parse(soapTmpl))
-	err := tmpl.Execute(data, g.pkg)
+	err := tmpl.Execute(data, struct {
+		Mixed bool
+	}{used["MixedContent"]})
 	if err != nil {
 		return nil, err
 	}
 
-	return data.Bytes(), nil
+	runtime, err := pruneRuntime(data.String(), code...)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(runtime), nil
 }
 
 var reservedWords = map[string]string{
@@ -388,122 +767,303 @@ func normalize(value string) string {
 	return strings.Map(mapping, value)
 }
 
+// Go types of the XSD built-in types, by local name. Those Go has no
+// equivalent for are declared by the generated code, along with the SOAP
+// client.
 var xsd2GoTypes = map[string]string{
-	"string":        "string",
-	"token":         "string",
-	"float":         "float32",
-	"double":        "float64",
-	"decimal":       "float64",
-	"integer":       "int32",
-	"int":           "int32",
-	"short":         "int16",
-	"byte":          "int8",
-	"long":          "int64",
-	"boolean":       "bool",
-	"datetime":      "time.Time",
-	"date":          "time.Time",
-	"time":          "time.Time",
-	"base64binary":  "[]byte",
-	"hexbinary":     "[]byte",
-	"unsignedint":   "uint32",
-	"unsignedshort": "uint16",
-	"unsignedbyte":  "byte",
-	"unsignedlong":  "uint64",
-	"anytype":       "interface{}",
-}
-
-func toGoType(xsdType string) string {
-	// Handles name space, ie. xsd:string, xs:string
-	r := strings.Split(xsdType, ":")
+	"anyType":       "interface{}",
+	"anySimpleType": "string",
+	"anyAtomicType": "string",
+
+	"string":           "string",
+	"normalizedString": "string",
+	"token":            "string",
+	"language":         "string",
+	"Name":             "string",
+	"NCName":           "string",
+	"NMTOKEN":          "string",
+	"NMTOKENS":         "XSDStringList",
+	"ID":               "string",
+	"IDREF":            "string",
+	"IDREFS":           "XSDStringList",
+	"ENTITY":           "string",
+	"ENTITIES":         "XSDStringList",
+	"anyURI":           "string",
+	"QName":            "string",
+	"NOTATION":         "string",
+
+	"boolean": "bool",
+	"float":   "float32",
+	"double":  "float64",
+	"decimal": "float64",
+
+	"integer":            "int32",
+	"nonPositiveInteger": "int64",
+	"negativeInteger":    "int64",
+	"nonNegativeInteger": "uint64",
+	"positiveInteger":    "uint64",
+	"long":               "int64",
+	"int":                "int32",
+	"short":              "int16",
+	"byte":               "int8",
+	"unsignedLong":       "uint64",
+	"unsignedInt":        "uint32",
+	"unsignedShort":      "uint16",
+	"unsignedByte":       "byte",
+
+	"dateTime":          "XSDDateTime",
+	"dateTimeStamp":     "XSDDateTime",
+	"date":              "XSDDate",
+	"time":              "XSDTime",
+	"duration":          "XSDDuration",
+	"yearMonthDuration": "XSDDuration",
+	"dayTimeDuration":   "XSDDuration",
+	"gYear":             "XSDGYear",
+	"gYearMonth":        "XSDGYearMonth",
+	"gMonth":            "XSDGMonth",
+	"gMonthDay":         "XSDGMonthDay",
+	"gDay":              "XSDGDay",
+
+	"base64Binary": "XSDBase64Binary",
+	"hexBinary":    "XSDHexBinary",
+}
 
-	t := r[0]
+// Go types of the numeric built-ins whose values can't be held exactly by
+// the default ones, used with WithExactNumbers.
+var exactXSD2GoTypes = map[string]string{
+	"decimal":            "XSDDecimal",
+	"integer":            "*big.Int",
+	"nonPositiveInteger": "*big.Int",
+	"negativeInteger":    "*big.Int",
+	"nonNegativeInteger": "*big.Int",
+	"positiveInteger":    "*big.Int",
+}
 
-	if len(r) == 2 {
-		t = r[1]
+// Whether a Go type of a built-in is declared by the generated code, or is
+// *big.Int, and has its own text encoding.
+func isRuntimeType(goType string) bool {
+	return strings.HasPrefix(goType, "XSD") || goType == "*big.Int"
+}
+
+// Returns the Go type of a built-in by its local name.
+func (g *GoWSDL) builtinGoType(name string) (string, bool) {
+	if goType, ok := exactXSD2GoTypes[name]; ok && g.exactNumbers {
+		return goType, true
+	}
+	goType, ok := xsd2GoTypes[name]
+	return goType, ok
+}
+
+// Returns the Go type for a {namespace}local type reference: the generated
+// type for schema definitions, or the matching Go type for XSD built-ins.
+func (g *GoWSDL) toGoType(xsdType string) string {
+	if xsdType == "" {
+		return "string"
 	}
 
-	value := xsd2GoTypes[strings.ToLower(t)]
+	if mapping, ok := g.typeMapping(xsdType); ok {
+		return mapping.GoType
+	}
 
-	if value != "" {
+	if definition, ok := g.types[xsdType]; ok {
+		return "*" + g.goNames[definition]
+	}
+
+	ns, t := splitQName(xsdType)
+
+	if value, ok := g.builtinGoType(t); ok && xsdNamespaces[ns] {
+		return value
+	}
+
+	if definition := g.lookupType(xsdType); definition != nil {
+		return "*" + g.goNames[definition]
+	}
+
+	// References whose prefix could not be resolved, or from schemas
+	// without namespace, are assumed to be built-ins.
+	if value, ok := g.builtinGoType(t); ok && ns == "" {
 		return value
 	}
 
 	return "*" + replaceReservedWords(makePublic(t))
 }
 
-// Given a message, finds its type.
-//
-// I'm not very proud of this function but
-// it works for now and performance doesn't
-// seem critical at this point
+// Given a message, finds the Go type of its first part.
 func (g *GoWSDL) findType(message string) string {
-	message = stripns(message)
+	msg := g.lookupMessage(message)
+	if msg == nil {
+		return ""
+	}
 
-	for _, msg := range g.wsdl.Messages {
-		if msg.Name != message {
-			continue
-		}
+	// Assumes document/literal wrapped WS-I
+	if len(msg.Parts) == 0 {
+		// Message does not have parts. This could be a Port
+		// with HTTP binding, which is not currently supported.
+		log.Printf("[WARN] %s message doesn't have any parts, ignoring message...", msg.Name)
+		return ""
+	}
+
+	part := msg.Parts[0]
+	if part.Type != "" {
+		return strings.TrimPrefix(g.toGoType(part.Type), "*")
+	}
+
+	el := g.lookupElement(part.Element)
+	if el == nil {
+		return ""
+	}
+
+	if el.Type == "" && el.ComplexType == nil {
+		return replaceReservedWords(makePublic(el.Name))
+	}
+	return strings.TrimPrefix(g.elementGoType(el), "*")
+}
+
+// Returns the name of the element of a message whose Go type is a named
+// type, which any element may have, and must be given when encoding it.
+// Nothing is returned for elements having a struct of their own.
+func (g *GoWSDL) findElement(message string) *xml.Name {
+	msg := g.lookupMessage(message)
+	if msg == nil || len(msg.Parts) == 0 || msg.Parts[0].Element == "" {
+		return nil
+	}
 
-		// Assumes document/literal wrapped WS-I
-		if len(msg.Parts) == 0 {
-			// Message does not have parts. This could be a Port
-			// with HTTP binding or SOAP 1.2 binding, which are not currently
-			// supported.
-			log.Printf("[WARN] %s message doesn't have any parts, ignoring message...", msg.Name)
+	el := g.lookupElement(msg.Parts[0].Element)
+	if el == nil || el.Type == "" {
+		return nil
+	}
+	return &xml.Name{Space: g.namespaces[el], Local: el.Name}
+}
+
+// Returns the Go type of a global element: the type it references or the
+// struct generated for its inline complex type.
+func (g *GoWSDL) elementGoType(el *XSDElement) string {
+	if el.Type != "" {
+		return g.toGoType(el.Type)
+	}
+
+	if name := g.goNames[el]; name != "" {
+		return "*" + name
+	}
+
+	return g.toGoType(qname(xsdNamespace, "anyType"))
+}
+
+// Returns the namespace of an element: the target namespace of the schema
+// declaring it if global or qualified, nothing otherwise.
+func (g *GoWSDL) elementNamespace(el *XSDElement) string {
+	return g.namespaces[el]
+}
+
+// Finds the binding used to generate the client of a port type. Bindings
+// exposed through a service port win over unexposed ones, and SOAP 1.1
+// bindings win over SOAP 1.2 ones so existing clients keep their protocol.
+func (g *GoWSDL) findBinding(portType *WSDLPortType) *WSDLBinding {
+	var (
+		candidate *WSDLBinding
+		best      = -1
+	)
+
+	ref := qname(portType.namespace, portType.Name)
+	for _, binding := range g.wsdl.Binding {
+		if binding.Type != ref || !isSOAPBinding(binding) {
 			continue
 		}
 
-		part := msg.Parts[0]
-		if part.Type != "" {
-			return stripns(part.Type)
+		rank := 0
+		if g.findPort(binding) != nil {
+			rank += 2
+		}
+		if !isSOAP12Binding(binding) {
+			rank++
 		}
 
-		elRef := stripns(part.Element)
+		if rank > best {
+			candidate, best = binding, rank
+		}
+	}
+	return candidate
+}
 
-		for _, schema := range g.wsdl.Types.Schemas {
-			for _, el := range schema.Elements {
-				if strings.EqualFold(elRef, el.Name) {
-					if el.Type != "" {
-						return stripns(el.Type)
-					}
-					return el.Name
-				}
+// Finds the service port exposing the given binding.
+func (g *GoWSDL) findPort(binding *WSDLBinding) *WSDLPort {
+	ref := qname(binding.namespace, binding.Name)
+	for _, service := range g.wsdl.Service {
+		for _, port := range service.Ports {
+			if port.Binding == ref {
+				return port
 			}
 		}
 	}
-	return ""
+	return nil
+}
+
+func isSOAPBinding(binding *WSDLBinding) bool {
+	return binding.SOAPBinding != (WSDLSOAPBinding{}) || isSOAP12Binding(binding)
+}
+
+func isSOAP12Binding(binding *WSDLBinding) bool {
+	return binding.SOAP12Binding != (WSDLSOAPBinding{})
 }
 
-// TODO(c4milo): Add support for namespaces instead of striping them out
 // TODO(c4milo): improve runtime complexity if performance turns out to be an issue.
-func (g *GoWSDL) findSOAPAction(operation, portType string) string {
-	for _, binding := range g.wsdl.Binding {
-		if stripns(binding.Type) != portType {
+func (g *GoWSDL) findSOAPAction(operation string, portType *WSDLPortType) string {
+	binding := g.findBinding(portType)
+	if binding == nil {
+		return ""
+	}
+
+	for _, soapOp := range binding.Operations {
+		if soapOp.Name != operation {
 			continue
 		}
 
-		for _, soapOp := range binding.Operations {
-			if soapOp.Name == operation {
-				return soapOp.SOAPOperation.SOAPAction
-			}
+		if isSOAP12Binding(binding) {
+			return soapOp.SOAP12Operation.SOAPAction
 		}
+		return soapOp.SOAPOperation.SOAPAction
 	}
 	return ""
 }
 
-func (g *GoWSDL) findServiceAddress(name string) string {
+// Whether the client for the given port type has to speak SOAP 1.2.
+func (g *GoWSDL) isSOAP12(portType *WSDLPortType) bool {
+	binding := g.findBinding(portType)
+	return binding != nil && isSOAP12Binding(binding)
+}
+
+func (g *GoWSDL) findServiceAddress(portType *WSDLPortType) string {
+	if binding := g.findBinding(portType); binding != nil {
+		if port := g.findPort(binding); port != nil {
+			if isSOAP12Binding(binding) {
+				return port.SOAP12Address.Location
+			}
+			return port.SOAPAddress.Location
+		}
+	}
+
 	for _, service := range g.wsdl.Service {
 		for _, port := range service.Ports {
-			if port.Name == name {
-				return port.SOAPAddress.Location
+			if port.Name == portType.Name {
+				if port.SOAPAddress.Location != "" {
+					return port.SOAPAddress.Location
+				}
+				return port.SOAP12Address.Location
 			}
 		}
 	}
 	return ""
 }
 
-// TODO(c4milo): Add namespace support instead of stripping it
+// Returns the local part of a {namespace}local reference or of a prefixed
+// name.
 func stripns(xsdType string) string {
+	if strings.HasPrefix(xsdType, "{") {
+		if i := strings.Index(xsdType, "}"); i > 0 {
+			return xsdType[i+1:]
+		}
+	}
+
 	r := strings.Split(xsdType, ":")
 	t := r[0]
 
@@ -7,7 +7,11 @@ package gowsdl
 import (
 	"bytes"
 	"go/format"
+	"io/ioutil"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -59,3 +63,1330 @@ func TestVboxGeneratesWithoutSyntaxErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestSOAP12OnlyService(t *testing.T) {
+	g := GoWSDL{
+		file: "fixtures/soap12.wsdl",
+		pkg:  "myservice",
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operations := string(resp["operations"])
+	expected := []string{
+		"NewSOAP12Client(url, tls, auth)",
+		`url = "http://example.com/stockquote.asmx"`,
+		`service.client.Call("http://example.com/stockquote/GetQuote", request, response)`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(operations, e) {
+			t.Errorf("operations should contain %q\n%s", e, operations)
+		}
+	}
+}
+
+func TestWSDLImportsAreMerged(t *testing.T) {
+	g := GoWSDL{
+		file: "fixtures/import/orders.wsdl",
+		pkg:  "myservice",
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(g.wsdl.Messages) != 2 || len(g.wsdl.PortTypes) != 1 || len(g.wsdl.Binding) != 1 || len(g.wsdl.Service) != 1 {
+		t.Errorf("imported definitions were not merged: %d messages, %d port types, %d bindings, %d services",
+			len(g.wsdl.Messages), len(g.wsdl.PortTypes), len(g.wsdl.Binding), len(g.wsdl.Service))
+	}
+
+	if len(g.wsdl.Types.Schemas) != 2 {
+		t.Errorf("expected the inline schema and the schema it imports, got %d schemas", len(g.wsdl.Types.Schemas))
+	}
+
+	types := string(resp["types"])
+	if !strings.Contains(types, "type OrderLine struct") {
+		t.Errorf("types from imported schemas should be generated\n%s", types)
+	}
+
+	operations := string(resp["operations"])
+	expected := []string{
+		"(request *GetOrder) (*GetOrderResponse, error)",
+		`url = "http://example.com/orders"`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(operations, e) {
+			t.Errorf("operations should contain %q\n%s", e, operations)
+		}
+	}
+}
+
+func TestConflictingWSDLImports(t *testing.T) {
+	g := GoWSDL{
+		file: "fixtures/import/conflict.wsdl",
+		pkg:  "myservice",
+	}
+
+	_, err := g.Start()
+	if err == nil || !strings.Contains(err.Error(), `conflicting definitions of message "GetOrderIn"`) {
+		t.Errorf("expected a conflicting definitions error, got %v", err)
+	}
+}
+
+func TestSchemasOfImportedWSDLsAreMerged(t *testing.T) {
+	g := GoWSDL{
+		file: "fixtures/import/parts.wsdl",
+		pkg:  "myservice",
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"type ShippingDetails struct",
+		"type BillingDetails struct",
+		"type Money struct",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+
+	if strings.Contains(types, "Money2") {
+		t.Errorf("identical schema types should be generated once\n%s", types)
+	}
+}
+
+func TestSchemaLocationsAreResolvedFromTheirDocument(t *testing.T) {
+	file, err := filepath.Abs("fixtures/import/parts.wsdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The working directory has a types.xsd of its own, which must not
+	// shadow the ones next to the documents importing them.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir("fixtures/import/shipping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	g := GoWSDL{
+		file: file,
+		pkg:  "myservice",
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	if !strings.Contains(types, "type BillingDetails struct") {
+		t.Errorf("billing/types.xsd should be loaded from the billing document\n%s", types)
+	}
+}
+
+func TestConflictingSchemaTypes(t *testing.T) {
+	g := GoWSDL{
+		file: "fixtures/import/typeconflict.wsdl",
+		pkg:  "myservice",
+	}
+
+	_, err := g.Start()
+	if err == nil || !strings.Contains(err.Error(), `conflicting definitions of type "Money"`) {
+		t.Errorf("expected a conflicting definitions error, got %v", err)
+	}
+}
+
+func TestSameLocalNameInDifferentNamespaces(t *testing.T) {
+	g := GoWSDL{
+		file: "fixtures/namespaces.wsdl",
+		pkg:  "myservice",
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(g.unresolved) != 0 {
+		t.Errorf("all references should resolve, got %v", g.unresolved)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"type Address struct",
+		"type AddressShipping struct",
+		"Billing *Address `",
+		"Shipping *AddressShipping `",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+
+	operations := string(resp["operations"])
+	if !strings.Contains(operations, "(request *PlaceOrder) (*string, error)") {
+		t.Errorf("operation types should be resolved through their namespaces\n%s", operations)
+	}
+}
+
+func TestUnresolvedReferencesAreReported(t *testing.T) {
+	g := GoWSDL{
+		file: "fixtures/namespaces/unresolved.wsdl",
+		pkg:  "myservice",
+	}
+
+	err := g.unmarshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`fixtures/namespaces/unresolved.wsdl: undeclared namespace prefix "ext" in reference "ext:Scope"`,
+		`fixtures/namespaces/unresolved.wsdl: unresolved type reference "{urn:example:unresolved}Missing"`,
+		`fixtures/namespaces/unresolved.wsdl: unresolved type reference "{urn:example:unresolved}tag"`,
+		`fixtures/namespaces/unresolved.wsdl: unresolved type reference "{urn:example:unresolved}Region"`,
+		`fixtures/namespaces/unresolved.wsdl: unresolved type reference "{urn:example:unresolved}Typo"`,
+		`fixtures/namespaces/unresolved.wsdl: unresolved type reference "{urn:example:unresolved}Unknown"`,
+		`fixtures/namespaces/unresolved.wsdl: unresolved type reference "{urn:example:unresolved}Kind"`,
+		`fixtures/namespaces/unresolved.wsdl: unresolved type reference "{urn:example:unresolved}Text"`,
+	}
+
+	var reported []string
+	for _, err := range g.unresolved {
+		reported = append(reported, err.Error())
+	}
+
+	for _, e := range expected {
+		found := 0
+		for _, r := range reported {
+			if r == e {
+				found++
+			}
+		}
+		if found != 1 {
+			t.Errorf("expected %q to be reported once, got %q", e, reported)
+		}
+	}
+
+	for _, ref := range []string{"{urn:example:unresolved}tag", "{urn:example:unresolved}Region"} {
+		if g.lookupType(ref) != nil {
+			t.Errorf("%s should not resolve to a type of another name or namespace", ref)
+		}
+	}
+}
+
+func TestElementRefsAreResolved(t *testing.T) {
+	g := GoWSDL{
+		file: "fixtures/refs.wsdl",
+		pkg:  "myservice",
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"Identifier *Identifier `xml:\"urn:example:common Identifier\"`",
+		"Tag []string `xml:\"urn:example:common Tag,omitempty\"`",
+		"Status *StatusCode `xml:\"urn:example:common Status\"`",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+}
+
+func TestGroupsAreExpanded(t *testing.T) {
+	g := GoWSDL{
+		file: "fixtures/groups.wsdl",
+		pkg:  "myservice",
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"GivenName string `xml:\"urn:example:people GivenName\"`",
+		"FamilyName string `xml:\"urn:example:people FamilyName\"`",
+		"Email *string `xml:\"urn:example:people Email,omitempty\"`",
+		"Revision int32 `xml:\"revision,attr,omitempty\"`",
+		"CreatedBy string `xml:\"createdBy,attr,omitempty\"`",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+}
+
+func TestNestedParticles(t *testing.T) {
+	g := GoWSDL{
+		file: "fixtures/particles.wsdl",
+		pkg:  "myservice",
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"Iban *string `xml:\"Iban,omitempty\"`",
+		"Sequence OrderSequenceList `xml:\",any\"`",
+		"Choice OrderSequenceChoiceList `xml:\",any\"`",
+		"Note OrderNoteList `xml:\",any\"`",
+		"type OrderSequenceList []OrderSequence",
+		"return unmarshalGroup(d, start, s, true)",
+		"return unmarshalGroups(d, start, (*alias)(t), &t.Sequence, &t.Note)",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+}
+
+func TestParticlesRoundTrip(t *testing.T) {
+	roundTrip(t, "fixtures/particles.wsdl", "particles_test.go")
+}
+
+func TestChoiceStyles(t *testing.T) {
+	tests := []struct {
+		style    ChoiceStyle
+		expected []string
+	}{
+		{ChoiceFields, []string{
+			"Card *string `xml:\"Card,omitempty\"`",
+			"Iban *string `xml:\"Iban,omitempty\"`",
+		}},
+		{ChoiceStruct, []string{
+			"Choice OrderChoice `xml:\",any\"`",
+			"Card *string `xml:\"Card,omitempty\"`",
+			"Sequence *OrderChoiceSequence `xml:\",any\"`",
+			"func (c OrderChoice) Validate() error",
+			"return validateChoice(c, false)",
+		}},
+		{ChoiceInterface, []string{
+			"Choice OrderChoice `xml:\",any\"`",
+			"isOrderChoice()",
+			"func (*OrderChoiceCard) isOrderChoice() {}",
+			"registerChoice((*OrderChoice)(nil), (*OrderChoiceCard)(nil), (*OrderChoiceSequence)(nil))",
+			"return unmarshalGroups(d, start, (*alias)(t), &t.Choice, &t.Sequence, &t.Note)",
+			`errs.occurs("Card|Iban|Bic", t.Choice, 1, 1)`,
+		}},
+	}
+
+	for _, test := range tests {
+		g, err := NewGoWSDL("fixtures/particles.wsdl", "myservice", false, WithChoiceStyle(test.style))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := g.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		types := string(resp["types"])
+		for _, e := range test.expected {
+			if !strings.Contains(types, e) {
+				t.Errorf("types generated with choice style %d should contain %q\n%s", test.style, e, types)
+			}
+		}
+	}
+}
+
+func TestChoiceStylesRoundTrip(t *testing.T) {
+	roundTrip(t, "fixtures/particles.wsdl", "choice_struct_test.go", WithChoiceStyle(ChoiceStruct))
+	roundTrip(t, "fixtures/particles.wsdl", "choice_interface_test.go", WithChoiceStyle(ChoiceInterface))
+}
+
+func TestPolymorphicTypes(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/polymorphism.wsdl", "myservice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"type AnyAnimal interface",
+		"func (t *Animal) GetAnimal() *Animal",
+		"type AnimalValue struct",
+		"Animal []AnimalValue `xml:\"urn:example:zoo Animal,omitempty\"`",
+		"Guard DogValue `xml:\"urn:example:zoo Guard\"`",
+		`registerDerived((*AnyAnimal)(nil), xml.Name{Space: "urn:example:zoo", Local: "Puppy"}, (*Puppy)(nil))`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+
+	// The anonymous type of Pet is named for the generated code only: it
+	// can't be given as xsi:type nor referenced.
+	if !strings.Contains(types, "type GetAnimalsPet struct") {
+		t.Errorf("types should contain GetAnimalsPet\n%s", types)
+	}
+	if strings.Contains(types, `Local: "GetAnimalsPet"}`) {
+		t.Errorf("GetAnimalsPet should not be registered as a derived type\n%s", types)
+	}
+	if g.lookupType("{urn:example:zoo}GetAnimalsPet") != nil {
+		t.Error("GetAnimalsPet should not resolve")
+	}
+}
+
+func TestPolymorphicTypesRoundTrip(t *testing.T) {
+	roundTrip(t, "fixtures/polymorphism.wsdl", "polymorphism_test.go")
+}
+
+func TestSubstitutionGroups(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/substitution.wsdl", "myservice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"Shape ShapeSubstitutionList `xml:\",any\"`",
+		"Label LabelSubstitution `xml:\",any\"`",
+		"return unmarshalGroups(d, start, (*alias)(t), &t.Shape, &t.Label)",
+		`registerSubstitute((*ShapeSubstitution)(nil), xml.Name{Space: "urn:example:drawing", Local: "Circle"}, (*CircleType)(nil))`,
+		`registerSubstitute((*ShapeSubstitution)(nil), xml.Name{Space: "urn:example:drawing", Local: "RoundedSquare"}, (*RoundedSquare)(nil))`,
+		`registerSubstitute((*LabelSubstitution)(nil), xml.Name{Space: "urn:example:drawing", Local: "Label"}, (*string)(nil))`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+
+	// The head is abstract, only its members can occur.
+	if strings.Contains(types, `Local: "Shape"}`) {
+		t.Errorf("abstract head Shape should not be registered\n%s", types)
+	}
+}
+
+func TestSubstitutionGroupsRoundTrip(t *testing.T) {
+	roundTrip(t, "fixtures/substitution.wsdl", "substitution_test.go")
+}
+
+func TestListAndUnionTypes(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/lists.wsdl", "myservice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"type Sizes []int32",
+		"type Colors []Color",
+		"type Weights []float64",
+		"return unmarshalList(text, l)",
+		"type SizeOrColor struct",
+		"return unmarshalUnion(text, &u.Lexical, &u.Value, (*int32)(nil), (*Color)(nil), (*bool)(nil))",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+}
+
+func TestListAndUnionTypesRoundTrip(t *testing.T) {
+	roundTrip(t, "fixtures/lists.wsdl", "lists_test.go")
+}
+
+func TestBuiltinTypes(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/builtins.wsdl", "myservice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"Date *Date `xml:\"urn:example:calendar date\"`",
+		"On XSDDate `xml:\"urn:example:calendar On\"`",
+		"Start XSDDateTime `xml:\"urn:example:calendar Start\"`",
+		"At XSDTime `xml:\"urn:example:calendar At\"`",
+		"Length XSDDuration `xml:\"urn:example:calendar Length\"`",
+		"Year XSDGYear `xml:\"urn:example:calendar Year\"`",
+		"DayOfMonth XSDGDay `xml:\"urn:example:calendar DayOfMonth\"`",
+		"type Years []XSDGYear",
+		"Kind string `xml:\"urn:example:calendar Kind\"`",
+		"Tags XSDStringList `xml:\"urn:example:calendar Tags\"`",
+		"Attendees uint64 `xml:\"urn:example:calendar Attendees\"`",
+		"Logo XSDBase64Binary `xml:\"urn:example:calendar Logo\"`",
+		"Checksum XSDHexBinary `xml:\"urn:example:calendar Checksum\"`",
+		"Entities XSDStringList `xml:\"entities,attr,omitempty\"`",
+		"type Period XSDDuration",
+		"return XSDDuration(v).MarshalText()",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+}
+
+func TestDateTypesRoundTrip(t *testing.T) {
+	roundTrip(t, "fixtures/builtins.wsdl", "dates_test.go")
+}
+
+func TestExactNumbers(t *testing.T) {
+	tests := []struct {
+		exact    bool
+		expected []string
+	}{
+		{false, []string{
+			"type Amount float64",
+			"type Count int32",
+			"Rate float64 `xml:\"urn:example:billing Rate\"`",
+			"Id int32 `xml:\"urn:example:billing Id\"`",
+			"Sequence uint64 `xml:\"sequence,attr,omitempty\"`",
+		}},
+		{true, []string{
+			"type Amount XSDDecimal",
+			"type Count big.Int",
+			"return (*big.Int)(v).MarshalText()",
+			"return (*Count)(v).UnmarshalText(text)",
+			"type Ids []big.Int",
+			"Rate XSDDecimal `xml:\"urn:example:billing Rate\"`",
+			"Id *big.Int `xml:\"urn:example:billing Id\"`",
+			"Quantity int32 `xml:\"urn:example:billing Quantity\"`",
+			"Sequence *big.Int `xml:\"sequence,attr,omitempty\"`",
+		}},
+	}
+
+	for _, test := range tests {
+		g, err := NewGoWSDL("fixtures/numbers.wsdl", "myservice", false, WithExactNumbers(test.exact))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := g.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		types := string(resp["types"])
+		for _, e := range test.expected {
+			if !strings.Contains(types, e) {
+				t.Errorf("exact %v: types should contain %q\n%s", test.exact, e, types)
+			}
+		}
+	}
+}
+
+func TestNillableFields(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/nillable.wsdl", "myservice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"Count int32 `xml:\"urn:example:inventory Count\"`",
+		"Note *string `xml:\"urn:example:inventory Note,omitempty\"`",
+		"Price Nillable[int32] `xml:\"urn:example:inventory Price\"`",
+		"Location *Nillable[string] `xml:\"urn:example:inventory Location,omitempty\"`",
+		"Tag []Nillable[string] `xml:\"urn:example:inventory Tag,omitempty\"`",
+		"Remark *Nillable[string] `xml:\"urn:example:inventory Remark,omitempty\"`",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+
+	if !strings.Contains(string(resp["soap"]), "type Nillable[T any] struct") {
+		t.Error("runtime should declare Nillable")
+	}
+
+	// Code generated for schemas without nillable elements needs no
+	// generics, thus no Go 1.18.
+	g, err = NewGoWSDL("fixtures/particles.wsdl", "myservice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(resp["soap"]), "Nillable") {
+		t.Errorf("runtime should not declare Nillable\n%s", resp["soap"])
+	}
+}
+
+func TestDefaultValues(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/defaults.wsdl", "myservice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"func NewSettings() *Settings {",
+		`setDefault(&t.Retries, "3")`,
+		`setDefault(&t.Mode, "auto")`,
+		`setDefault(&t.Ratio, "0.5")`,
+		`setDefault(&t.Region, "eu")`,
+		`setDefault(&t.Version, "1.0")`,
+		"t.Device = new(Device)",
+		`errs.fixed("Ratio", t.Ratio, "0.5")`,
+		`errs.fixed("@version", t.Version, "1.0")`,
+		"func (t *Router) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+
+	// Repeated elements get no default, and the decoding method of an
+	// extended type would be promoted to the types derived from it.
+	for _, e := range []string{`setDefault(&t.Tag`, "func (t *Device) UnmarshalXML("} {
+		if strings.Contains(types, e) {
+			t.Errorf("types should not contain %q\n%s", e, types)
+		}
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/attributes.wsdl", "myservice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(g.unresolved) != 0 {
+		t.Errorf("all references should resolve, got %v", g.unresolved)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"Lang string `xml:\"http://www.w3.org/XML/1998/namespace lang,attr,omitempty\"`",
+		"Id string `xml:\"urn:example:common id,attr\"`",
+		"Title string `xml:\"title,attr,omitempty\"`",
+		"Pages int32 `xml:\"pages,attr\"`",
+		"Draft bool `xml:\"urn:example:docs draft,attr,omitempty\"`",
+		"By string `xml:\"urn:example:common by,attr,omitempty\"`",
+		"At *XSDDateTime `xml:\"at,attr,omitempty\"`",
+		`errs.occurs("@id", t.Id, 1, 1)`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+
+	if strings.Contains(types, "Legacy") {
+		t.Errorf("prohibited attributes should have no field\n%s", types)
+	}
+}
+
+func TestAttributesRoundTrip(t *testing.T) {
+	roundTrip(t, "fixtures/attributes.wsdl", "attributes_test.go")
+}
+
+func TestElementForms(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/forms.wsdl", "myservice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"Number string `xml:\"urn:example:orders Number\"`",
+		"Note string `xml:\"Note\"`",
+		"Customer *Customer `xml:\"urn:example:orders Customer\"`",
+		"Priority int32 `xml:\"urn:example:common Priority\"`",
+		"Name string `xml:\"Name\"`",
+		"Confirmation string `xml:\"urn:example:orders Confirmation\"`",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+
+	operations := string(resp["operations"])
+	call := `namedElement{xml.Name{Space: "urn:example:orders", Local: "PlaceOrder"}, request}`
+	if !strings.Contains(operations, call) {
+		t.Errorf("requests of a named type should be sent as their element, got\n%s", operations)
+	}
+}
+
+func TestTypeMappings(t *testing.T) {
+	var options []Option
+	for _, spec := range []string{
+		"{urn:example:crm}Address=example.com/addresses.Address",
+		"{urn:example:crm}Country=example.com/addresses.Country",
+		"xs:dateTime=*time.Time",
+		"xs:date=string",
+	} {
+		name, mapping, err := ParseTypeMapping(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		options = append(options, WithTypeMapping(name, mapping))
+	}
+
+	g, err := NewGoWSDL("fixtures/mappings.wsdl", "myservice", false, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := string(resp["header"])
+	for _, e := range []string{`"example.com/addresses"`, "var _ addresses.Address", "var _ *time.Time"} {
+		if !strings.Contains(header, e) {
+			t.Errorf("header should contain %q\n%s", e, header)
+		}
+	}
+	if strings.Count(header, `"time"`) != 1 {
+		t.Errorf("header should import time once\n%s", header)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"Home addresses.Address `xml:\"urn:example:crm Home\"`",
+		"Country addresses.Country `xml:\"urn:example:crm Country\"`",
+		"Since *time.Time `xml:\"urn:example:crm Since\"`",
+		"Birthday string `xml:\"urn:example:crm Birthday\"`",
+		"type PostalAddress struct",
+		"errs.check(\"\", t.Address)",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+	for _, e := range []string{"type Address struct", "type Country ", "AnyAddress"} {
+		if strings.Contains(types, e) {
+			t.Errorf("types should not contain %q\n%s", e, types)
+		}
+	}
+}
+
+func TestTypeMappingImports(t *testing.T) {
+	var options []Option
+	for _, spec := range []string{
+		"{urn:example:crm}Address=example.com/a/models.Address",
+		"{urn:example:crm}Country=example.com/b/models.Country",
+		"xs:dateTime=*example.com/go-civil/v2.DateTime",
+		"xs:date=example.com/xml.Date",
+	} {
+		name, mapping, err := ParseTypeMapping(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		options = append(options, WithTypeMapping(name, mapping))
+	}
+
+	g, err := NewGoWSDL("fixtures/mappings.wsdl", "myservice", false, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := string(resp["header"])
+	for _, e := range []string{
+		`models "example.com/a/models"`,
+		`models2 "example.com/b/models"`,
+		`civil "example.com/go-civil/v2"`,
+		`xml2 "example.com/xml"`,
+		"var _ models2.Country",
+	} {
+		if !strings.Contains(header, e) {
+			t.Errorf("header should contain %q\n%s", e, header)
+		}
+	}
+
+	types := string(resp["types"])
+	for _, e := range []string{
+		"Home models.Address `xml:\"urn:example:crm Home\"`",
+		"Country models2.Country `xml:\"urn:example:crm Country\"`",
+		"Since *civil.DateTime `xml:\"urn:example:crm Since\"`",
+		"Birthday xml2.Date `xml:\"urn:example:crm Birthday\"`",
+	} {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+
+	source := append(append(resp["header"], resp["types"]...), resp["soap"]...)
+	if _, err := format.Source(source); err != nil {
+		t.Errorf("generated code should parse: %v", err)
+	}
+}
+
+func TestParseTypeMapping(t *testing.T) {
+	tests := []struct {
+		spec    string
+		name    string
+		mapping TypeMapping
+	}{
+		{"xs:dateTime=cloud.google.com/go/civil.DateTime", "{http://www.w3.org/2001/XMLSchema}dateTime", TypeMapping{"civil.DateTime", "cloud.google.com/go/civil"}},
+		{"{urn:a}Address=*example.com/shared.Address", "{urn:a}Address", TypeMapping{"*shared.Address", "example.com/shared"}},
+		{"Ids=[]gopkg.in/ids.v2.ID", "Ids", TypeMapping{"[]ids.ID", "gopkg.in/ids.v2"}},
+		{"xsd:anyURI=string", "{http://www.w3.org/2001/XMLSchema}anyURI", TypeMapping{"string", ""}},
+		{"Id=example.com/ids/v2.ID", "Id", TypeMapping{"ids.ID", "example.com/ids/v2"}},
+		{"Model=example.com/go-models.Model", "Model", TypeMapping{"models.Model", "example.com/go-models"}},
+		{"Tag=*example.com/my-tags.Tag", "Tag", TypeMapping{"*mytags.Tag", "example.com/my-tags"}},
+		{"Kind=example.com/type.Kind", "Kind", TypeMapping{"pkgtype.Kind", "example.com/type"}},
+	}
+	for _, test := range tests {
+		name, mapping, err := ParseTypeMapping(test.spec)
+		if err != nil || name != test.name || mapping != test.mapping {
+			t.Errorf("%s: got %q %+v %v, want %q %+v", test.spec, name, mapping, err, test.name, test.mapping)
+		}
+	}
+
+	for _, spec := range []string{"Address", "=string", "tns:Address=string", "Address=example.com/shared"} {
+		if _, _, err := ParseTypeMapping(spec); err == nil {
+			t.Errorf("%s should not parse", spec)
+		}
+	}
+}
+
+func TestValidationMethods(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/validation.wsdl", "myservice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		`patterns: []*regexp.Regexp{regexp.MustCompile("^(?:[A-Z]{2,4})$"), regexp.MustCompile("^(?:[0-9]{3})$")},`,
+		`minExclusive: "0",`,
+		`maxExclusive: "1000",`,
+		`totalDigits: "5",`,
+		`fractionDigits: "2",`,
+		`whiteSpace: "collapse",`,
+		`SizeExtralarge Size = "extra large"`,
+		"// Validate doesn't check the assertions of Even:",
+		"//\t$value mod 2 = 0",
+		"type ShortCode Code",
+		"if err := Code(v).Validate(); err != nil {",
+		`maxInclusive: "100",`,
+		`enumeration: []string{"open", "closed"},`,
+		"func (v QName) Validate() error {",
+		`errs.occurs("Item", t.Item, 1, -1)`,
+		`errs.occurs("Tag", t.Tag, 0, 2)`,
+		`errs.occurs("@id", t.Id, 1, 1)`,
+		`errs.check("@status", t.Status)`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+
+	// Go can't compile the pattern of QName.
+	if strings.Contains(types, "facetsQName") {
+		t.Errorf("QName should have no facets\n%s", types)
+	}
+
+	if !strings.Contains(string(resp["soap"]), "func (s *SOAPClient) SetValidation(validate bool)") {
+		t.Error("SOAP client should let requests be validated")
+	}
+}
+
+func TestValidationRoundTrip(t *testing.T) {
+	roundTrip(t, "fixtures/validation.wsdl", "validation_test.go")
+}
+
+func TestPatternsAreTranslated(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		matches    []string
+		mismatches []string
+	}{
+		{`\d{3}`, []string{"123", "١٢٣"}, []string{"12a"}},
+		{`[\d.]+`, []string{"1.٢"}, []string{"1,2"}},
+		{`\D+`, []string{"abc"}, []string{"a١"}},
+		{`\w+`, []string{"été"}, []string{"a-b"}},
+		{`[\W]`, []string{"-"}, []string{"a"}},
+		{`a\sb`, []string{"a b", "a\tb"}, []string{"a\fb"}},
+		{`a^b$`, []string{"a^b$"}, []string{"ab"}},
+		{`[^a]$`, []string{"b$"}, []string{"a$", "b"}},
+		{`a.b`, []string{"a-b"}, []string{"a\rb"}},
+		{`\[\^\]`, []string{"[^]"}, nil},
+	}
+	for _, test := range tests {
+		patterns := goPatterns("Test", []XSDRestrictionValue{{Value: test.pattern}})
+		if len(patterns) != 1 {
+			t.Errorf("%s should be translated, got %q", test.pattern, patterns)
+			continue
+		}
+		re := regexp.MustCompile(patterns[0])
+		for _, s := range test.matches {
+			if !re.MatchString(s) {
+				t.Errorf("%s (%s) should match %q", test.pattern, patterns[0], s)
+			}
+		}
+		for _, s := range test.mismatches {
+			if re.MatchString(s) {
+				t.Errorf("%s (%s) should not match %q", test.pattern, patterns[0], s)
+			}
+		}
+	}
+
+	// Go has no character class subtraction, nor a way to complement \w or
+	// \S within a class.
+	for _, pattern := range []string{`[a-z-[aeiou]]+`, `[\w-]`, `[\S]`} {
+		if patterns := goPatterns("Test", []XSDRestrictionValue{{Value: `[0-9]`}, {Value: pattern}}); patterns != nil {
+			t.Errorf("%s should not be validated, got %q", pattern, patterns)
+		}
+	}
+}
+
+func TestWildcards(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/wildcards.wsdl", "myservice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"Any []AnyElement `xml:\",any\"`",
+		"AnyAttr []xml.Attr `xml:\",any,attr\"`",
+		`// Elements matching xs:any namespace="##other" processContents="lax".`,
+		`// Attributes matching xs:anyAttribute namespace="##any" processContents="lax".`,
+		`errs.wildcard("Any", t.Any, true, "urn:example:registry", "")`,
+		`errs.wildcard("@", t.AnyAttr, true, "urn:example:registry", "")`,
+		`errs.wildcard("Any", t.Any, false, "urn:example:values", "")`,
+		`errs.occurs("Any", t.Any, 0, 1)`,
+		"start.Attr = withoutDeclarations(start.Attr)",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+
+	// Each wildcard of Note has its field, in its place, and is checked.
+	note := types[strings.Index(types, "type Note struct"):]
+	note = note[:strings.Index(note, "return errs.err()")]
+	expected = []string{
+		"Any []AnyElement `xml:\",any\"`",
+		"Text string `xml:\"urn:example:registry Text\"`",
+		"Any2 []AnyElement `xml:\",any\"`",
+		`&anyField{elements: &t.Any2, max: 1, after: []string{"Text"}, other: true, namespaces: []string{"urn:example:registry", ""}}`,
+		`errs.wildcard("Any", t.Any, false, "")`,
+		`errs.wildcard("Any2", t.Any2, true, "urn:example:registry", "")`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(note, e) {
+			t.Errorf("Note should contain %q\n%s", e, note)
+		}
+	}
+	if strings.Index(note, "Any []AnyElement") > strings.Index(note, "Text string") || strings.Index(note, "Text string") > strings.Index(note, "Any2 []AnyElement") {
+		t.Errorf("the fields of Note should be in the order of its content\n%s", note)
+	}
+}
+
+func TestWildcardsRoundTrip(t *testing.T) {
+	roundTrip(t, "fixtures/wildcards.wsdl", "wildcards_test.go")
+}
+
+func TestMixedContent(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/mixed.wsdl", "myservice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"Content MixedContent `xml:\"-\"`",
+		"return unmarshalMixed(d, start, (*alias)(t), &t.Content)",
+		"func (t RichText) MarshalXML(e *xml.Encoder, start xml.StartElement) error",
+		"return marshalMixed(e, start, (*alias)(&t), t.Content)",
+		`start.Name = xml.Name{Space: "urn:example:documents", Local: "Comment"}`,
+		"func (t *Abstract) Text() string",
+		"func (t *Summary) Text() string",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+
+	// The methods of the extended Summary would be promoted to Abstract.
+	for _, e := range []string{"func (t *Summary) UnmarshalXML", "func (t Summary) MarshalXML"} {
+		if strings.Contains(types, e) {
+			t.Errorf("types should not contain %q\n%s", e, types)
+		}
+	}
+}
+
+func TestMixedContentRoundTrip(t *testing.T) {
+	roundTrip(t, "fixtures/mixed.wsdl", "mixed_test.go")
+}
+
+func TestRestrictions(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/restrictions.wsdl", "myservice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"Sku string `xml:\"urn:example:shipping Sku\"`",
+		"Item []*Item `xml:\"item,omitempty\"`",
+		"Item []string `xml:\"item,omitempty\"`",
+		"errs.fixed(\"@kind\", t.Kind, \"local\")",
+		"Country *string `xml:\"urn:example:shipping Country,omitempty\"`",
+		"errs.occurs(\"@id\", t.Id, 1, 1)",
+		"Value string `xml:\",chardata\"`",
+		"type CountryCodeValue string",
+		"Value *CountryCodeValue `xml:\",chardata\"`",
+		"CurrencyValueEUR CurrencyValue = \"EUR\"",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+
+	// Prohibited attributes and elements not restated are left out.
+	for name, field := range map[string]string{"LocalAddress": "Verified", "CountryCode": "Version"} {
+		decl := types[strings.Index(types, "type "+name+" struct"):]
+		decl = decl[:strings.Index(decl, "func ")]
+		if strings.Contains(decl, field) {
+			t.Errorf("%s should not have a %s field\n%s", name, field, decl)
+		}
+	}
+
+	// The parsed schema is left as declared.
+	localAddress := g.lookupType("{urn:example:shipping}LocalAddress").(*XSDComplexType)
+	if localAddress.Content() != nil || localAddress.Attributes != nil {
+		t.Errorf("LocalAddress should only declare its restriction, got %+v", localAddress)
+	}
+	countryCode := g.lookupType("{urn:example:shipping}CountryCode").(*XSDComplexType)
+	if countryCode.SimpleContent.Extension.Base != "" {
+		t.Errorf("CountryCode should only declare its restriction, got %+v", countryCode.SimpleContent)
+	}
+}
+
+func TestAnonymousSimpleTypes(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/anonymous.wsdl", "myservice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"type CurrencyType string",
+		"Currency *CurrencyType `xml:\"urn:example:orders Currency\"`",
+		// OrderStatusType is taken by a global type.
+		"OrderStatusType2Open OrderStatusType2 = \"open\"",
+		"Status *OrderStatusType2 `xml:\"urn:example:orders Status\"`",
+		"type OrderLineQuantityType int32",
+		"type OrderLineTagsType []string",
+		"OrderLineUnitTypeBox OrderLineUnitType = \"box\"",
+		"Unit *OrderLineUnitType `xml:\"unit,attr,omitempty\"`",
+		"OrderPriorityTypeHigh OrderPriorityType = \"high\"",
+		"errs.check(\"@priority\", t.Priority)",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+}
+
+func TestAnonymousComplexTypes(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/anonymous.wsdl", "myservice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := string(resp["types"])
+	expected := []string{
+		"Line []*OrderLine `xml:\"urn:example:orders Line\"`",
+		"type OrderLine struct",
+		"setDefault(&t.Unit, \"piece\")",
+		"Delivery *PlaceOrderDelivery `xml:\"urn:example:orders Delivery,omitempty\"`",
+		"Window *PlaceOrderDeliveryWindow `xml:\"urn:example:orders Window\"`",
+		"type PlaceOrderDeliveryWindow struct",
+	}
+	for _, e := range expected {
+		if !strings.Contains(types, e) {
+			t.Errorf("types should contain %q\n%s", e, types)
+		}
+	}
+
+	g, err = NewGoWSDL("fixtures/anonymous.wsdl", "myservice", false, WithInlineTypes(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types = string(resp["types"])
+	if !strings.Contains(types, "Line []struct {") {
+		t.Errorf("types should contain an inline Line struct\n%s", types)
+	}
+	if strings.Contains(types, "type OrderLine struct") {
+		t.Errorf("types should not contain OrderLine\n%s", types)
+	}
+}
+
+func TestRuntimeIsPruned(t *testing.T) {
+	tests := []struct {
+		fixture    string
+		emitted    []string
+		pruned     []string
+		imported   []string
+		unimported []string
+	}{
+		{
+			"fixtures/test.wsdl",
+			[]string{"func NewSOAPClient(", "func (s *SOAPClient) Call(", "type SOAPHeader struct", "type ValidationErrors []*ValidationError", "func validate("},
+			[]string{"unmarshalGroups", "registerDerived", "registerSubstitute", "AnyElement", "MixedContent", "Nillable", "setDefault", "validateFacets", "XSDDuration", "XSDDateTime", "func (e *ValidationErrors) occurs("},
+			[]string{`"encoding/xml"`, `"net/http"`},
+			[]string{`"math/big"`, `"regexp"`, `"encoding/hex"`},
+		},
+		{
+			"fixtures/builtins.wsdl",
+			[]string{"type XSDDuration struct", "func NewXSDDuration(", "type XSDDate struct", "func NewXSDDate(", "func parseTimezone("},
+			[]string{"unmarshalGroups", "AnyElement", "Nillable"},
+			[]string{`"time"`, `"encoding/base64"`},
+			nil,
+		},
+		{
+			"fixtures/wildcards.wsdl",
+			[]string{"type AnyElement struct", "func (e *ValidationErrors) wildcard("},
+			[]string{"MixedContent", "Nillable", "registerSubstitute"},
+			nil,
+			[]string{`"math/big"`},
+		},
+	}
+
+	for _, test := range tests {
+		g, err := NewGoWSDL(test.fixture, "myservice", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := g.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		soap := string(resp["soap"])
+		for _, e := range test.emitted {
+			if !strings.Contains(soap, e) {
+				t.Errorf("%s: runtime should contain %q", test.fixture, e)
+			}
+		}
+		for _, e := range test.pruned {
+			if strings.Contains(soap, e) {
+				t.Errorf("%s: runtime should not contain %q", test.fixture, e)
+			}
+		}
+
+		header := string(resp["header"])
+		for _, e := range test.imported {
+			if !strings.Contains(header, e) {
+				t.Errorf("%s: header should import %s\n%s", test.fixture, e, header)
+			}
+		}
+		for _, e := range test.unimported {
+			if strings.Contains(header, e) {
+				t.Errorf("%s: header should not import %s\n%s", test.fixture, e, header)
+			}
+		}
+	}
+}
+
+func TestGeneratedPackagesBuild(t *testing.T) {
+	fixtures, err := filepath.Glob("fixtures/*.wsdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fixture := range fixtures {
+		// vim.wsdl needs schemas that aren't fixtures.
+		if fixture == filepath.Join("fixtures", "vim.wsdl") {
+			continue
+		}
+		fixture := fixture
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			t.Parallel()
+			roundTrip(t, fixture, "")
+			roundTrip(t, fixture, "", WithChoiceStyle(ChoiceInterface), WithExactNumbers(true))
+		})
+	}
+}
+
+// roundTrip generates the code for a fixture as package gen, in a module of
+// its own, along with a test program of fixtures/roundtrip, and runs it, so
+// the generated code is compiled and exercised on sample documents. The
+// programs are built with the roundtrip tag, which keeps them out of this
+// package. Without a program, the generated code is only built and vetted.
+func roundTrip(t *testing.T, fixture, program string, options ...Option) {
+	if testing.Short() {
+		t.Skip("skipping round trip in short mode")
+	}
+
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("skipping round trip: go tool not found")
+	}
+
+	g, err := NewGoWSDL(fixture, "gen", false, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := new(bytes.Buffer)
+	data.Write(resp["header"])
+	data.Write(resp["types"])
+	data.Write(resp["operations"])
+	data.Write(resp["soap"])
+
+	source, err := format.Source(data.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"go.mod": []byte("module gen\n\ngo 1.18\n"),
+		"gen.go": source,
+	}
+	args := []string{"vet", "."}
+	if program != "" {
+		files["main_test.go"], err = ioutil.ReadFile(filepath.Join("fixtures", "roundtrip", program))
+		if err != nil {
+			t.Fatal(err)
+		}
+		args = []string{"test", "-tags", "roundtrip", "-count=1", "."}
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), content, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(goTool, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s %s: %v\n%s", fixture, program, err, out)
+	}
+}
@@ -5,24 +5,14 @@
 package gowsdl
 
 var headerTmpl = `
-package {{.}}
+package {{.Package}}
 
-import (
-	"encoding/xml"
-	"time"
- 	"bytes"
- 	"crypto/tls"
- 	"io/ioutil"
-	"net/http"
-	"log"
-	"net"
-
-	{{/*range .Imports*/}}
-		{{/*.*/}}
-	{{/*end*/}}
+import ({{range .Imports}}
+	{{.}}{{end}}
+{{range .MappingImports}}
+	{{.}}{{end}}
 )
-
+{{with .Mappings}}
 // against "unused imports"
-var _ time.Time
-var _ xml.Name
-`
+{{range .}}var _ {{.GoType}}
+{{end}}{{end}}`
@@ -0,0 +1,116 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import "strings"
+
+// polymorphicType is a complex type that is abstract or extended by other
+// complex types. Fields of such a type hold any type derived from it,
+// picked by xsi:type when decoding.
+type polymorphicType struct {
+	// Go name of the base type.
+	Name string
+	// Interface implemented by the base type and its derived types.
+	Interface string
+	// Struct holding a value of any of those types in a field.
+	Value string
+	// The base type followed by every type derived from it.
+	Types []*derivedType
+}
+
+// derivedType is a complex type that may be given as xsi:type.
+type derivedType struct {
+	GoName    string
+	Namespace string
+	Local     string
+}
+
+// indexHierarchy builds the type hierarchy of every complex type extended
+// by other types or declared abstract.
+func (g *GoWSDL) indexHierarchy() {
+	g.polymorphicTypes = nil
+	g.polymorphicNames = make(map[string]*polymorphicType)
+
+	derived := make(map[*XSDComplexType][]*XSDComplexType)
+	var bases []*XSDComplexType
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, complexType := range schema.ComplexTypes {
+			// Types declared for anonymous ones have no name to be given
+			// as xsi:type.
+			if g.anonymousTypes[complexType] {
+				continue
+			}
+
+			if complexType.Abstract && derived[complexType] == nil && !g.isMapped(complexType) {
+				derived[complexType] = []*XSDComplexType{}
+				bases = append(bases, complexType)
+			}
+
+			// Mapped types are neither generated nor registered.
+			base, ok := g.lookupType(complexType.ComplexContent.Extension.Base).(*XSDComplexType)
+			if !ok || base == complexType || g.isMapped(base) || g.isMapped(complexType) {
+				continue
+			}
+
+			if _, seen := derived[base]; !seen {
+				bases = append(bases, base)
+			}
+			derived[base] = append(derived[base], complexType)
+		}
+	}
+
+	for _, base := range bases {
+		name := g.goNames[base]
+		pt := &polymorphicType{Name: name}
+
+		g.declareGoName(&pt.Interface, "", "Any"+name)
+		pt.Interface = g.goNames[&pt.Interface]
+		g.declareGoName(&pt.Value, "", name+"Value")
+		pt.Value = g.goNames[&pt.Value]
+
+		// Walks the hierarchy breadth first, guarding against cycles.
+		seen := map[*XSDComplexType]bool{}
+		queue := []*XSDComplexType{base}
+		for len(queue) > 0 {
+			complexType := queue[0]
+			queue = queue[1:]
+			if seen[complexType] {
+				continue
+			}
+			seen[complexType] = true
+
+			pt.Types = append(pt.Types, &derivedType{
+				GoName:    g.goNames[complexType],
+				Namespace: g.namespaces[complexType],
+				Local:     complexType.Name,
+			})
+			queue = append(queue, derived[complexType]...)
+		}
+
+		g.polymorphicTypes = append(g.polymorphicTypes, pt)
+		g.polymorphicNames[name] = pt
+	}
+}
+
+// fieldType returns the Go type of a field given the Go type of its schema
+// type: values of polymorphic types are held by their Value struct.
+func (g *GoWSDL) fieldType(goType string) string {
+	if pt := g.polymorphicNames[strings.TrimPrefix(goType, "*")]; pt != nil {
+		return pt.Value
+	}
+	return goType
+}
+
+// isExtended tells whether a complex type is extended by other types or
+// declared abstract, fields of the type holding any type derived from it.
+func (g *GoWSDL) isExtended(complexType *XSDComplexType) bool {
+	return g.polymorphicNames[g.goNames[complexType]] != nil
+}
+
+// listPolymorphicTypes returns the polymorphic types to generate.
+func (g *GoWSDL) listPolymorphicTypes() []*polymorphicType {
+	return g.polymorphicTypes
+}
@@ -0,0 +1,165 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"go/token"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// typeMapping returns the Go type a type reference is mapped to with
+// WithTypeMapping, if any. References to built-ins match mappings in any
+// XSD namespace, and those whose prefix could not be resolved match
+// mappings of built-ins too.
+func (g *GoWSDL) typeMapping(ref string) (TypeMapping, bool) {
+	if len(g.typeMappings) == 0 {
+		return TypeMapping{}, false
+	}
+
+	if mapping, ok := g.typeMappings[ref]; ok {
+		return mapping, true
+	}
+
+	ns, local := splitQName(ref)
+	if xsdNamespaces[ns] || ns == "" && g.types[ref] == nil {
+		if _, builtin := xsd2GoTypes[local]; builtin {
+			mapping, ok := g.typeMappings[qname(xsdNamespace, local)]
+			return mapping, ok
+		}
+	}
+
+	if definition := g.lookupType(ref); definition != nil {
+		return g.mappingOf(definition)
+	}
+	return TypeMapping{}, false
+}
+
+// mappingOf returns the mapping of a global simple or complex type, if it
+// is mapped to an existing Go type and must not be generated.
+func (g *GoWSDL) mappingOf(definition interface{}) (TypeMapping, bool) {
+	var name string
+	switch t := definition.(type) {
+	case *XSDSimpleType:
+		name = t.Name
+	case *XSDComplexType:
+		name = t.Name
+	default:
+		return TypeMapping{}, false
+	}
+	if g.anonymousTypes[definition] {
+		return TypeMapping{}, false
+	}
+
+	mapping, ok := g.typeMappings[qname(g.namespaces[definition], name)]
+	return mapping, ok
+}
+
+// isMapped tells whether a global simple or complex type is mapped to an
+// existing Go type.
+func (g *GoWSDL) isMapped(definition interface{}) bool {
+	_, ok := g.mappingOf(definition)
+	return ok
+}
+
+// mappedTypes returns the names of the mapped types in order.
+func (g *GoWSDL) mappedTypes() []string {
+	names := make([]string, 0, len(g.typeMappings))
+	for name := range g.typeMappings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Names a mapped package can't be imported as: those of the packages
+// imported by the header, of the unexported declarations of the runtime,
+// and of predeclared identifiers.
+var reservedImportNames = func() map[string]bool {
+	names := map[string]bool{}
+	for _, name := range strings.Fields("any append bool byte cap close complex complex64 complex128 copy delete error false float32 float64 imag int int8 int16 int32 int64 iota len make new nil panic print println real recover rune string true uint uint8 uint16 uint32 uint64 uintptr") {
+		names[name] = true
+	}
+	for _, importPath := range runtimeImports {
+		names[path.Base(importPath)] = true
+	}
+	for _, m := range regexp.MustCompile(`(?m)^(?:(?:func|type|var|const) |\t)([a-z_]\w*)\b`).FindAllStringSubmatch(soapTmpl, -1) {
+		names[m[1]] = true
+	}
+	return names
+}()
+
+// importName returns the name a package is imported as: the last element
+// of its path, or the one before a major version such as v2, without
+// the go- prefix, the -go suffix and a version suffix such as .v2, and
+// stripped of what can't be in a Go identifier.
+func importName(importPath string) string {
+	base := path.Base(importPath)
+	if len(base) > 1 && base[0] == 'v' && strings.Trim(base[1:], "0123456789") == "" {
+		if dir := path.Dir(importPath); dir != "." {
+			base = path.Base(dir)
+		}
+	}
+	base = strings.TrimSuffix(strings.TrimPrefix(base, "go-"), "-go")
+	if i := strings.Index(base, "."); i > 0 {
+		base = base[:i]
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, base)
+	if name == "" || unicode.IsDigit(rune(name[0])) || token.IsKeyword(name) {
+		name = "pkg" + name
+	}
+	return name
+}
+
+// aliasImports gives the packages of mapped types distinct names, imported
+// with an alias, and qualifies the Go types of the mappings with them.
+// Packages the header imports when used keep their name.
+func (g *GoWSDL) aliasImports() {
+	g.mappingImports = nil
+	aliases := make(map[string]string)
+	used := make(map[string]bool)
+	for _, name := range g.mappedTypes() {
+		mapping := g.typeMappings[name]
+		if mapping.ImportPath == "" {
+			continue
+		}
+
+		elem := strings.TrimLeft(mapping.GoType, "*[]")
+		modifiers := mapping.GoType[:len(mapping.GoType)-len(elem)]
+		qualifier, typeName := importName(mapping.ImportPath), elem
+		if dot := strings.LastIndex(elem, "."); dot >= 0 {
+			qualifier, typeName = elem[:dot], elem[dot+1:]
+		}
+
+		alias, ok := aliases[mapping.ImportPath]
+		switch {
+		case ok:
+		case isRuntimeImport(mapping.ImportPath):
+			alias = path.Base(mapping.ImportPath)
+		default:
+			base := importName(qualifier)
+			alias = base
+			for n := 2; used[alias] || reservedImportNames[alias]; n++ {
+				alias = base + strconv.Itoa(n)
+			}
+			used[alias] = true
+			g.mappingImports = append(g.mappingImports, alias+" "+strconv.Quote(mapping.ImportPath))
+		}
+		aliases[mapping.ImportPath] = alias
+
+		mapping.GoType = modifiers + alias + "." + typeName
+		g.typeMappings[name] = mapping
+	}
+}
@@ -0,0 +1,21 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+// isMixed tells whether a complex type has mixed content, declared on the
+// type or its complex content, or on a type it extends: the content of
+// types extending a mixed type is mixed too.
+func (g *GoWSDL) isMixed(complexType *XSDComplexType) bool {
+	seen := map[*XSDComplexType]bool{}
+	for complexType != nil && !seen[complexType] {
+		if complexType.Mixed || complexType.ComplexContent.Mixed {
+			return true
+		}
+		seen[complexType] = true
+
+		complexType, _ = g.lookupType(complexType.ComplexContent.Extension.Base).(*XSDComplexType)
+	}
+	return false
+}
@@ -6,6 +6,7 @@ package gowsdl
 
 var opsTmpl = `
 {{range .}}
+	{{$portTypeDef := .}}
 	{{$portType := .Name | makePublic}}
 	type {{$portType}} struct {
 		client *SOAPClient
@@ -13,9 +14,13 @@ var opsTmpl = `
 
 	func New{{$portType}}(url string, tls bool, auth *BasicAuth) *{{$portType}} {
 		if url == "" {
-			url = {{findServiceAddress .Name | printf "%q"}}
+			url = {{findServiceAddress . | printf "%q"}}
 		}
-		client := NewSOAPClient(url, tls, auth)
+		{{if isSOAP12 .}}
+			client := NewSOAP12Client(url, tls, auth)
+		{{else}}
+			client := NewSOAPClient(url, tls, auth)
+		{{end}}
 
 		return &{{$portType}}{
 			client: client,
@@ -24,9 +29,10 @@ var opsTmpl = `
 
 	{{range .Operations}}
 		{{$faults := len .Faults}}
-		{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}}
-		{{$soapAction := findSOAPAction .Name $portType}}
-		{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
+		{{$requestType := findType .Input.Message}}
+		{{$requestElement := findElement .Input.Message}}
+		{{$soapAction := findSOAPAction .Name $portTypeDef}}
+		{{$responseType := findType .Output.Message}}
 
 		{{/*if ne $soapAction ""*/}}
 		{{if gt $faults 0}}
@@ -36,7 +42,7 @@ var opsTmpl = `
 		{{if ne .Doc ""}}/* {{.Doc}} */{{end}}
 		func (service *{{$portType}}) {{makePublic .Name | replaceReservedWords}} ({{if ne $requestType ""}}request *{{$requestType}}{{end}}) (*{{$responseType}}, error) {
 			response := new({{$responseType}})
-			err := service.client.Call("{{$soapAction}}", {{if ne $requestType ""}}request{{else}}nil{{end}}, response)
+			err := service.client.Call("{{$soapAction}}", {{if ne $requestType ""}}{{with $requestElement}}namedElement{xml.Name{Space: {{printf "%q" .Space}}, Local: {{printf "%q" .Local}}}, request}{{else}}request{{end}}{{else}}nil{{end}}, response)
 			if err != nil {
 				return nil, err
 			}
@@ -0,0 +1,131 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Option customizes the code generated by GoWSDL.
+type Option func(*GoWSDL)
+
+// ChoiceStyle selects how xs:choice groups are generated.
+type ChoiceStyle int
+
+const (
+	// ChoiceFields generates every alternative as a field of the enclosing
+	// struct.
+	ChoiceFields ChoiceStyle = iota
+	// ChoiceStruct generates a struct with one pointer field per
+	// alternative, only one of which may be set, and a Validate method.
+	ChoiceStruct
+	// ChoiceInterface generates a sealed interface implemented by one type
+	// per alternative.
+	ChoiceInterface
+)
+
+var choiceStyles = map[string]ChoiceStyle{
+	"fields":    ChoiceFields,
+	"struct":    ChoiceStruct,
+	"interface": ChoiceInterface,
+}
+
+// ParseChoiceStyle returns the choice style named "fields", "struct" or
+// "interface".
+func ParseChoiceStyle(name string) (ChoiceStyle, error) {
+	style, ok := choiceStyles[name]
+	if !ok {
+		return ChoiceFields, fmt.Errorf("unknown choice style %q", name)
+	}
+	return style, nil
+}
+
+// WithChoiceStyle sets how xs:choice groups are generated. Choices that may
+// occur more than once are always generated as slices of structs.
+func WithChoiceStyle(style ChoiceStyle) Option {
+	return func(g *GoWSDL) {
+		g.choiceStyle = style
+	}
+}
+
+// WithExactNumbers maps xs:decimal to XSDDecimal, which keeps the decimal
+// value as it is, and xs:integer and the integer types without bounds
+// derived from it to *big.Int, instead of float64 and fixed size integers.
+func WithExactNumbers(exact bool) Option {
+	return func(g *GoWSDL) {
+		g.exactNumbers = exact
+	}
+}
+
+// WithInlineTypes generates the anonymous complex types of local elements as
+// struct types nested in the struct of the type declaring the element, as
+// earlier versions did, instead of named types such as OrderLine for the
+// Line element of the Order type.
+func WithInlineTypes(inline bool) Option {
+	return func(g *GoWSDL) {
+		g.inlineTypes = inline
+	}
+}
+
+// TypeMapping is an existing Go type used for a schema type instead of the
+// one it would be generated as.
+type TypeMapping struct {
+	// Go type as written in the generated code, such as civil.DateTime or
+	// *addresses.Address.
+	GoType string
+	// Import path of the package declaring GoType, if not built-in.
+	ImportPath string
+}
+
+// WithTypeMapping maps the built-in or schema type named qname, in its
+// {namespace}local form, to an existing Go type. Schema types mapped that
+// way are not generated.
+func WithTypeMapping(qname string, mapping TypeMapping) Option {
+	return func(g *GoWSDL) {
+		if g.typeMappings == nil {
+			g.typeMappings = make(map[string]TypeMapping)
+		}
+		g.typeMappings[qname] = mapping
+	}
+}
+
+// ParseTypeMapping parses a type mapping given as qname=type, where qname is
+// {namespace}local, xs:local for XSD built-ins or local for types without
+// namespace, and type is a Go type qualified by the import path of its
+// package, such as cloud.google.com/go/civil.DateTime, *time.Time or
+// []string.
+func ParseTypeMapping(spec string) (string, TypeMapping, error) {
+	i := strings.LastIndex(spec, "=")
+	if i <= 0 || i == len(spec)-1 {
+		return "", TypeMapping{}, fmt.Errorf("invalid type mapping %q, expected qname=type", spec)
+	}
+	name, goType := strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+
+	if !strings.HasPrefix(name, "{") {
+		if j := strings.Index(name, ":"); j >= 0 {
+			if prefix := name[:j]; prefix != "xs" && prefix != "xsd" {
+				return "", TypeMapping{}, fmt.Errorf("invalid type mapping %q, unknown prefix %q", spec, prefix)
+			}
+			name = qname(xsdNamespace, name[j+1:])
+		}
+	}
+
+	// Splits the package path off the qualified type name. The package is
+	// named as importName says, and renamed if that name is taken when
+	// generating code.
+	elem := strings.TrimLeft(goType, "*[]")
+	modifiers := goType[:len(goType)-len(elem)]
+	dot := strings.LastIndex(elem, ".")
+	if dot < strings.LastIndex(elem, "/")+1 {
+		if strings.Contains(elem, "/") {
+			return "", TypeMapping{}, fmt.Errorf("invalid type mapping %q, missing type name", spec)
+		}
+		return name, TypeMapping{GoType: goType}, nil
+	}
+
+	importPath := elem[:dot]
+	return name, TypeMapping{GoType: modifiers + importName(importPath) + elem[dot:], ImportPath: importPath}, nil
+}
@@ -0,0 +1,441 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Kinds of group types.
+const (
+	groupList            = "list"
+	groupChoiceStruct    = "struct"
+	groupChoiceInterface = "interface"
+)
+
+// groupType is the Go type generated for a compositor that can't be
+// flattened into the enclosing struct: a sequence, choice or group
+// reference that may occur more than once, or a choice generated with
+// ChoiceStruct or ChoiceInterface.
+type groupType struct {
+	Kind string
+	// Name of the struct holding one occurrence, or of the choice type.
+	Name string
+	// Name of the slice type holding every occurrence of a list.
+	ListName string
+	// Field of the enclosing struct holding the group.
+	Field string
+	// Compositor whose children make up the group.
+	Content *XSDParticle
+	// Whether occurrences of a list hold just one of their fields.
+	Choice bool
+	// Whether a choice may have no alternative set.
+	Optional bool
+	// Alternatives of a choice.
+	Alternatives []*choiceAlternative
+}
+
+// FieldType returns the Go type of the field holding the group.
+func (gt *groupType) FieldType() string {
+	if gt.Kind == groupList {
+		return gt.ListName
+	}
+	return gt.Name
+}
+
+// choiceAlternative is an alternative of a choice. Alternatives other than
+// single elements of choice structs get a struct of their own.
+type choiceAlternative struct {
+	Name     string
+	Field    string
+	Particle *XSDParticle
+}
+
+// isRepeated tells whether a maxOccurs value allows more than one occurrence.
+func isRepeated(maxOccurs string) bool {
+	if maxOccurs == "unbounded" {
+		return true
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(maxOccurs))
+	return err == nil && n > 1
+}
+
+// pointer returns a Go type as a pointer, unless it already is a pointer or
+// a slice.
+func pointer(goType string) string {
+	if strings.HasPrefix(goType, "*") || strings.HasPrefix(goType, "[]") {
+		return goType
+	}
+	return "*" + goType
+}
+
+// elementFieldType returns the Go type of the field generated for a local
+// element or element reference given the Go type of its schema type:
+// optional elements are pointers and nillable ones are wrapped in
+// Nillable.
+func (g *GoWSDL) elementFieldType(el *XSDElement, goType string) string {
+	nillable := el.Nillable
+	if el.Ref != "" {
+		if decl := g.lookupElement(el.Ref); decl != nil {
+			nillable = decl.Nillable
+		}
+	}
+
+	fieldType := g.fieldType(goType)
+	switch {
+	case nillable:
+		fieldType = "Nillable[" + valueType(fieldType) + "]"
+		if g.optionalElements[el] && !isRepeated(el.MaxOccurs) {
+			fieldType = "*" + fieldType
+		}
+	case fieldType != goType:
+		// Values of polymorphic types may be empty already.
+	case g.optionalElements[el] && !isRepeated(el.MaxOccurs):
+		fieldType = pointer(fieldType)
+	}
+
+	if isRepeated(el.MaxOccurs) {
+		return "[]" + fieldType
+	}
+	return fieldType
+}
+
+// isOptional tells whether a local element or element reference may be
+// missing, which it is encoded as when empty.
+func (g *GoWSDL) isOptional(el *XSDElement) bool {
+	return g.optionalElements[el]
+}
+
+// indexParticles walks the content model of the definitions of every schema
+// declaring a group type for each compositor that can't be flattened.
+func (g *GoWSDL) indexParticles() {
+	g.groupTypes = nil
+	g.groupParticles = make(map[*XSDParticle]*groupType)
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, group := range schema.Groups {
+			// The compositor of a group is generated where the group is
+			// referenced, only nested ones are named after the group.
+			if content := group.Content(); content != nil {
+				for _, child := range content.Particles {
+					g.indexParticle(replaceReservedWords(makePublic(group.Name)), child)
+				}
+			}
+		}
+
+		for _, element := range schema.Elements {
+			if name, ok := g.goNames[element]; ok {
+				g.indexComplexType(name, element.ComplexType)
+			}
+		}
+
+		for _, complexType := range schema.ComplexTypes {
+			g.indexComplexType(g.goNames[complexType], complexType)
+		}
+	}
+}
+
+func (g *GoWSDL) indexComplexType(parent string, complexType *XSDComplexType) {
+	complexType = g.restated(complexType)
+	g.indexParticle(parent, complexType.Content())
+	g.indexParticle(parent, complexType.ComplexContent.Extension.Content())
+}
+
+func (g *GoWSDL) indexParticle(parent string, p *XSDParticle) {
+	if p == nil || g.groupParticles[p] != nil {
+		return
+	}
+
+	switch p.Kind {
+	case "element":
+		el := p.Element
+		if el.Type == "" && el.Ref == "" && el.ComplexType != nil {
+			if g.isMixed(el.ComplexType) {
+				log.Printf("[WARN] element %s has an anonymous mixed type, its text won't be kept", el.Name)
+			}
+			g.indexComplexType(parent+replaceReservedWords(makePublic(el.Name)), el.ComplexType)
+		}
+		return
+	case "group":
+		group := g.lookupGroup(p.Group)
+		if group == nil || group.Content() == nil {
+			return
+		}
+
+		_, local := splitQName(p.Group.Ref)
+		if isRepeated(p.MaxOccurs) {
+			g.declareGroupType(parent, local, p, group.Content())
+		} else if group.Content().Kind == "choice" && g.choiceStyle != ChoiceFields {
+			g.declareChoiceType(parent, local, p, group.Content())
+		}
+		return
+	case "any":
+		return
+	}
+
+	if isRepeated(p.MaxOccurs) {
+		parent = g.declareGroupType(parent, p.Kind, p, p).Name
+	} else if p.Kind == "choice" && g.choiceStyle != ChoiceFields {
+		g.declareChoiceType(parent, p.Kind, p, p)
+		return
+	}
+
+	for _, child := range p.Particles {
+		g.indexParticle(parent, child)
+	}
+}
+
+func (g *GoWSDL) declareGroupType(parent, local string, p, content *XSDParticle) *groupType {
+	gt := &groupType{
+		Kind:    groupList,
+		Content: content,
+		Choice:  content.Kind == "choice",
+	}
+
+	gt.Name = g.declareGroupName(gt, parent+makePublic(local))
+	gt.Field = strings.TrimPrefix(gt.Name, parent)
+	gt.ListName = g.declareGroupName(&gt.ListName, gt.Name+"List")
+
+	g.groupTypes = append(g.groupTypes, gt)
+	g.groupParticles[p] = gt
+	return gt
+}
+
+func (g *GoWSDL) declareChoiceType(parent, local string, p, content *XSDParticle) *groupType {
+	gt := &groupType{
+		Kind:     groupChoiceStruct,
+		Content:  content,
+		Optional: p.MinOccurs == "0" || content.MinOccurs == "0",
+	}
+	if g.choiceStyle == ChoiceInterface {
+		gt.Kind = groupChoiceInterface
+	}
+
+	gt.Name = g.declareGroupName(gt, parent+makePublic(local))
+	gt.Field = strings.TrimPrefix(gt.Name, parent)
+
+	g.groupTypes = append(g.groupTypes, gt)
+	g.groupParticles[p] = gt
+
+	for _, child := range content.Particles {
+		alternative := &choiceAlternative{Particle: child}
+		gt.Alternatives = append(gt.Alternatives, alternative)
+
+		local := child.Kind
+		switch child.Kind {
+		case "element":
+			local = child.Element.Name
+			if child.Element.Ref != "" {
+				_, local = splitQName(child.Element.Ref)
+			}
+
+			if gt.Kind == groupChoiceStruct && (child.Element.Type != "" || child.Element.Ref != "") {
+				continue
+			}
+		case "group":
+			_, local = splitQName(child.Group.Ref)
+		}
+
+		alternative.Name = g.declareGroupName(alternative, gt.Name+replaceReservedWords(makePublic(local)))
+		alternative.Field = strings.TrimPrefix(alternative.Name, gt.Name)
+		g.indexParticle(alternative.Name, child)
+	}
+
+	return gt
+}
+
+func (g *GoWSDL) declareGroupName(definition interface{}, name string) string {
+	g.declareGoName(definition, "", name)
+	return g.goNames[definition]
+}
+
+// groupOf returns the group type generated for a particle, or nil if its
+// content is generated in place.
+func (g *GoWSDL) groupOf(p *XSDParticle) *groupType {
+	return g.groupParticles[p]
+}
+
+// decoderField is a field handed to unmarshalGroups by the UnmarshalXML
+// method of a struct: a group or substitution group, or the elements of a
+// wildcard along with what tells them apart from those of the others.
+type decoderField struct {
+	Field    string
+	Wildcard bool
+	Max      int
+	// Elements declared between the previous wildcard and this one.
+	After      []string
+	Other      bool
+	Namespaces []string
+}
+
+// groupDecoderFields returns the fields of a complex type holding groups,
+// substitution groups or wildcards when the type needs its own UnmarshalXML
+// to hand them their elements: encoding/xml hands unknown elements to the
+// first group or wildcard only, and can't decode into a choice interface or
+// tell the members of a substitution group from other elements.
+func (g *GoWSDL) groupDecoderFields(complexType *XSDComplexType) []*decoderField {
+	var fields, wildcards []*decoderField
+	var names []string
+	needed := false
+
+	var walk func(p *XSDParticle)
+	walk = func(p *XSDParticle) {
+		if p == nil {
+			return
+		}
+
+		if gt := g.groupParticles[p]; gt != nil {
+			fields = append(fields, &decoderField{Field: gt.Field})
+			needed = needed || gt.Kind == groupChoiceInterface
+			names = g.elementNames(names, p)
+			return
+		}
+
+		switch p.Kind {
+		case "element":
+			names = g.elementNames(names, p)
+			if el := p.Element; el.Ref != "" {
+				if head := g.lookupElement(el.Ref); g.substitutionOf(head) != nil {
+					fields = append(fields, &decoderField{Field: fieldName(head.Name)})
+					needed = true
+				}
+			}
+		case "group":
+			if group := g.lookupGroup(p.Group); group != nil {
+				walk(group.Content())
+			}
+		case "any":
+			wildcard := &decoderField{Field: g.wildcardFields[p], Wildcard: true, Max: occurrences(p.MaxOccurs), After: names}
+			wildcard.Namespaces, wildcard.Other = g.wildcardNamespaces(p.Any)
+			wildcards, names = append(wildcards, wildcard), nil
+		case "sequence", "choice", "all":
+			for _, child := range p.Particles {
+				walk(child)
+			}
+		}
+	}
+
+	complexType = g.restated(complexType)
+	walk(complexType.Content())
+	walk(complexType.ComplexContent.Extension.Content())
+
+	// The first wildcard takes the elements before it.
+	if len(wildcards) > 0 {
+		wildcards[0].After = nil
+	}
+
+	if len(wildcards) > 1 || len(wildcards) > 0 && len(fields) > 0 {
+		fields, needed = append(fields, wildcards...), true
+	}
+	if len(fields) > 1 || needed {
+		return fields
+	}
+	return nil
+}
+
+// Appends the local names of the elements of a particle to names.
+func (g *GoWSDL) elementNames(names []string, p *XSDParticle) []string {
+	if p == nil {
+		return names
+	}
+
+	switch p.Kind {
+	case "element":
+		name := p.Element.Name
+		if p.Element.Ref != "" {
+			_, name = splitQName(p.Element.Ref)
+		}
+		return append(names, name)
+	case "group":
+		if group := g.lookupGroup(p.Group); group != nil {
+			return g.elementNames(names, group.Content())
+		}
+	case "sequence", "choice", "all":
+		for _, child := range p.Particles {
+			names = g.elementNames(names, child)
+		}
+	}
+	return names
+}
+
+// listGroupTypes returns the group types to generate.
+func (g *GoWSDL) listGroupTypes() []*groupType {
+	return g.groupTypes
+}
+
+// indexOccurrences finds the local elements and element references that
+// may be missing from the struct generated for their content model: those
+// with minOccurs="0", in an optional compositor, or alternatives of a
+// choice flattened into the struct. Elements of groups referenced in
+// several places are optional if they are in any of them.
+func (g *GoWSDL) indexOccurrences() {
+	g.optionalElements = make(map[*XSDElement]bool)
+
+	var walk func(p *XSDParticle, optional bool)
+	walkComplexType := func(complexType *XSDComplexType) {
+		complexType = g.restated(complexType)
+		walk(complexType.Content(), false)
+		walk(complexType.ComplexContent.Extension.Content(), false)
+	}
+
+	walk = func(p *XSDParticle, optional bool) {
+		if p == nil {
+			return
+		}
+
+		// Group types are structs of their own, required relative to them.
+		if gt := g.groupParticles[p]; gt != nil {
+			for _, child := range gt.Content.Particles {
+				walk(child, gt.Choice && len(gt.Content.Particles) > 1)
+			}
+			for _, alternative := range gt.Alternatives {
+				if alternative.Name != "" {
+					walk(alternative.Particle, false)
+				}
+			}
+			return
+		}
+
+		optional = optional || p.MinOccurs == "0"
+
+		switch p.Kind {
+		case "element":
+			el := p.Element
+			if optional {
+				g.optionalElements[el] = true
+			}
+			if el.Type == "" && el.Ref == "" && el.ComplexType != nil {
+				walkComplexType(el.ComplexType)
+			}
+		case "group":
+			if group := g.lookupGroup(p.Group); group != nil {
+				walk(group.Content(), optional)
+			}
+		case "choice":
+			for _, child := range p.Particles {
+				walk(child, optional || len(p.Particles) > 1)
+			}
+		case "sequence", "all":
+			for _, child := range p.Particles {
+				walk(child, optional)
+			}
+		}
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, element := range schema.Elements {
+			if element.Type == "" && element.ComplexType != nil {
+				walkComplexType(element.ComplexType)
+			}
+		}
+
+		for _, complexType := range schema.ComplexTypes {
+			walkComplexType(complexType)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const (
+	wsdlNamespace       = "http://schemas.xmlsoap.org/wsdl/"
+	wsdlSOAPNamespace   = "http://schemas.xmlsoap.org/wsdl/soap/"
+	wsdlSOAP12Namespace = "http://schemas.xmlsoap.org/wsdl/soap12/"
+	xsdNamespace        = "http://www.w3.org/2001/XMLSchema"
+	xmlNamespace        = "http://www.w3.org/XML/1998/namespace"
+	soapEncNamespace    = "http://schemas.xmlsoap.org/soap/encoding/"
+)
+
+// Namespaces whose elements carry QName references that have to be resolved.
+var qnameNamespaces = map[string]bool{
+	wsdlNamespace:                         true,
+	wsdlSOAPNamespace:                     true,
+	wsdlSOAP12Namespace:                   true,
+	xsdNamespace:                          true,
+	"http://www.w3.org/2000/10/XMLSchema": true,
+	"http://www.w3.org/1999/XMLSchema":    true,
+}
+
+// Attributes holding QName references to other WSDL or XSD definitions.
+var qnameAttributes = map[string]bool{
+	"type":              true,
+	"element":           true,
+	"message":           true,
+	"binding":           true,
+	"base":              true,
+	"ref":               true,
+	"substitutionGroup": true,
+	"itemType":          true,
+}
+
+// Attributes holding whitespace separated lists of QName references.
+var qnameListAttributes = map[string]bool{
+	"memberTypes": true,
+}
+
+// qname returns a reference in its {namespace}local form. References to
+// definitions without namespace are just the local name.
+func qname(namespace, local string) string {
+	if namespace == "" {
+		return local
+	}
+	return "{" + namespace + "}" + local
+}
+
+// splitQName splits a reference in {namespace}local form. References that
+// could not be resolved keep their prefix, which is dropped.
+func splitQName(ref string) (namespace, local string) {
+	if strings.HasPrefix(ref, "{") {
+		if i := strings.Index(ref, "}"); i > 0 {
+			return ref[1:i], ref[i+1:]
+		}
+	}
+	return "", stripns(ref)
+}
+
+// qnameResolver is a xml.TokenReader that rewrites the QName references of
+// WSDL and XSD elements, such as type="tns:Foo", into their {namespace}local
+// form using the xmlns declarations in scope.
+type qnameResolver struct {
+	decoder  *xml.Decoder
+	location string
+	scopes   []map[string]string
+	errors   []error
+}
+
+func newQNameResolver(data []byte, location string) *qnameResolver {
+	return &qnameResolver{
+		decoder:  xml.NewDecoder(bytes.NewReader(data)),
+		location: location,
+		scopes: []map[string]string{
+			{"xml": xmlNamespace},
+		},
+	}
+}
+
+func (r *qnameResolver) Token() (xml.Token, error) {
+	token, err := r.decoder.Token()
+	if err != nil {
+		return token, err
+	}
+
+	switch t := token.(type) {
+	case xml.StartElement:
+		scope := r.scopes[len(r.scopes)-1]
+		for _, attr := range t.Attr {
+			if attr.Name.Space == "xmlns" || attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+				scope = copyScope(scope)
+				break
+			}
+		}
+		for _, attr := range t.Attr {
+			if attr.Name.Space == "xmlns" {
+				scope[attr.Name.Local] = attr.Value
+			} else if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+				scope[""] = attr.Value
+			}
+		}
+		r.scopes = append(r.scopes, scope)
+
+		if !qnameNamespaces[t.Name.Space] {
+			return t.Copy(), nil
+		}
+
+		t = t.Copy()
+		for i, attr := range t.Attr {
+			if attr.Name.Space == wsdlNamespace && attr.Name.Local == "arrayType" {
+				// The type of the items of SOAP-encoded arrays, followed by
+				// their dimensions.
+				ref, dimensions := attr.Value, ""
+				if j := strings.Index(ref, "["); j >= 0 {
+					ref, dimensions = ref[:j], ref[j:]
+				}
+				t.Attr[i].Value = r.resolve(ref, scope) + dimensions
+				continue
+			}
+			if attr.Name.Space != "" {
+				continue
+			}
+
+			if qnameAttributes[attr.Name.Local] {
+				t.Attr[i].Value = r.resolve(attr.Value, scope)
+			} else if qnameListAttributes[attr.Name.Local] {
+				refs := strings.Fields(attr.Value)
+				for j, ref := range refs {
+					refs[j] = r.resolve(ref, scope)
+				}
+				t.Attr[i].Value = strings.Join(refs, " ")
+			}
+		}
+		return t, nil
+
+	case xml.EndElement:
+		r.scopes = r.scopes[:len(r.scopes)-1]
+	}
+
+	return xml.CopyToken(token), nil
+}
+
+// Resolves a QName against the given scope. QNames with undeclared prefixes
+// are reported and returned as they are.
+func (r *qnameResolver) resolve(value string, scope map[string]string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return value
+	}
+
+	prefix, local := "", value
+	if i := strings.Index(value, ":"); i >= 0 {
+		prefix, local = value[:i], value[i+1:]
+	}
+
+	namespace, ok := scope[prefix]
+	if !ok && prefix != "" {
+		r.errors = append(r.errors, fmt.Errorf("%s: undeclared namespace prefix %q in reference %q", r.location, prefix, value))
+		return value
+	}
+
+	return qname(namespace, local)
+}
+
+func copyScope(scope map[string]string) map[string]string {
+	c := make(map[string]string, len(scope)+1)
+	for k, v := range scope {
+		c[k] = v
+	}
+	return c
+}
+
+// unmarshalDocument decodes a WSDL or XSD document resolving its QName
+// references, and records any reference that could not be resolved.
+func (g *GoWSDL) unmarshalDocument(data []byte, v interface{}, location string) error {
+	resolver := newQNameResolver(data, location)
+
+	err := xml.NewTokenDecoder(resolver).Decode(v)
+	if err != nil {
+		return fmt.Errorf("%s: %v", location, err)
+	}
+
+	g.unresolved = append(g.unresolved, resolver.errors...)
+	return nil
+}
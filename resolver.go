@@ -0,0 +1,616 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Identifiers declared by the generated SOAP client, which schema types must
+// not reuse.
+var runtimeNames = []string{
+	"SOAPEnvelope",
+	"SOAPHeader",
+	"SOAPBody",
+	"SOAPFault",
+	"SOAP12Fault",
+	"SOAP12FaultCode",
+	"SOAP12FaultReason",
+	"SOAP12FaultText",
+	"SOAP12FaultDetail",
+	"BasicAuth",
+	"SOAPClient",
+	"ValidationError",
+	"ValidationErrors",
+	"Nillable",
+	"XSDDecimal",
+	"XSDStringList",
+	"XSDBase64Binary",
+	"XSDHexBinary",
+	"XSDDateTime",
+	"XSDDate",
+	"XSDTime",
+	"XSDDuration",
+	"XSDGYear",
+	"XSDGYearMonth",
+	"XSDGMonth",
+	"XSDGMonthDay",
+	"XSDGDay",
+}
+
+// Namespaces of the XSD built-in types.
+var xsdNamespaces = map[string]bool{
+	xsdNamespace:                                true,
+	"http://www.w3.org/2000/10/XMLSchema":       true,
+	"http://www.w3.org/1999/XMLSchema":          true,
+	"http://schemas.xmlsoap.org/soap/encoding/": true,
+}
+
+// indexDefinitions indexes every global schema definition by its
+// {namespace}local name and assigns each generated Go type a unique name, so
+// definitions sharing a local name in different namespaces don't collide.
+func (g *GoWSDL) indexDefinitions() {
+	g.types = make(map[string]interface{})
+	g.elements = make(map[string]*XSDElement)
+	g.groups = make(map[string]*XSDGroup)
+	g.attributeGroups = make(map[string]*XSDAttributeGroup)
+	g.goNames = make(map[interface{}]string)
+	g.namespaces = make(map[interface{}]string)
+	g.usedNames = make(map[string]bool)
+
+	for _, name := range runtimeNames {
+		g.usedNames[name] = true
+	}
+
+	for _, portType := range g.wsdl.PortTypes {
+		g.usedNames[makePublic(portType.Name)] = true
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		ns := schema.TargetNamespace
+
+		for _, simpleType := range schema.SimpleType {
+			g.types[qname(ns, simpleType.Name)] = simpleType
+			g.namespaces[simpleType] = ns
+			g.declareGoName(simpleType, ns, simpleType.Name)
+		}
+
+		for _, element := range schema.Elements {
+			g.elements[qname(ns, element.Name)] = element
+			g.namespaces[element] = ns
+			if element.Type == "" && element.ComplexType != nil {
+				g.declareGoName(element, ns, element.Name)
+			}
+		}
+
+		for _, group := range schema.Groups {
+			g.groups[qname(ns, group.Name)] = group
+		}
+
+		for _, attributeGroup := range schema.AttributeGroups {
+			g.attributeGroups[qname(ns, attributeGroup.Name)] = attributeGroup
+		}
+
+		for _, complexType := range schema.ComplexTypes {
+			g.types[qname(ns, complexType.Name)] = complexType
+			g.namespaces[complexType] = ns
+			g.declareGoName(complexType, ns, complexType.Name)
+		}
+	}
+
+	g.indexAnonymousTypes()
+	g.indexRestrictions()
+	g.indexElementForms()
+	g.indexAttributes()
+	g.indexHierarchy()
+	g.indexSubstitutions()
+	g.indexParticles()
+	g.indexWildcards()
+	g.indexOccurrences()
+}
+
+// indexElementForms records the namespace of the local elements that must
+// be qualified: those declared with form="qualified" and those of schemas
+// declaring elementFormDefault="qualified".
+func (g *GoWSDL) indexElementForms() {
+	for _, schema := range g.wsdl.Types.Schemas {
+		walkDeclarations(schema, func(element *XSDElement) {
+			if element.Ref != "" {
+				return
+			}
+
+			form := element.Form
+			if form == "" {
+				form = schema.ElementFormDefault
+			}
+			if form == "qualified" {
+				g.namespaces[element] = schema.TargetNamespace
+			}
+		}, nil, nil)
+	}
+}
+
+// walkDeclarations calls element for every local element declared by a
+// schema, attribute for every local attribute and attribute reference, and
+// wildcard for every xs:any and xs:anyAttribute, any of which may be nil.
+func walkDeclarations(schema *XSDSchema, element func(*XSDElement), attribute func(*XSDAttribute), wildcard func(*XSDWildcard)) {
+	var walkParticle func(p *XSDParticle)
+	var walkComplexType func(complexType *XSDComplexType)
+
+	// Attribute groups are referenced, their attributes walked with the
+	// schema defining them.
+	walkAttributes := func(attributes []*XSDAttribute, anyAttribute *XSDWildcard) {
+		if attribute != nil {
+			for _, a := range attributes {
+				attribute(a)
+			}
+		}
+		if wildcard != nil && anyAttribute != nil {
+			wildcard(anyAttribute)
+		}
+	}
+
+	walkElementType := func(el *XSDElement) {
+		if el.ComplexType != nil {
+			walkComplexType(el.ComplexType)
+		}
+	}
+
+	walkParticle = func(p *XSDParticle) {
+		if p == nil {
+			return
+		}
+
+		switch p.Kind {
+		case "element":
+			if element != nil {
+				element(p.Element)
+			}
+			walkElementType(p.Element)
+		case "group":
+			if p.Group.Ref == "" {
+				walkParticle(p.Group.Content())
+			}
+		case "any":
+			if wildcard != nil {
+				wildcard(p.Any)
+			}
+		default:
+			for _, child := range p.Particles {
+				walkParticle(child)
+			}
+		}
+	}
+
+	// Restricted types are walked as declared, what they inherit being
+	// walked with the type declaring it.
+	walkComplexType = func(complexType *XSDComplexType) {
+		if restriction := complexType.ComplexContent.Restriction; restriction.Base != "" {
+			walkParticle(restriction.Content())
+			walkAttributes(restriction.Attributes, restriction.AnyAttribute)
+		} else {
+			walkParticle(complexType.Content())
+			walkAttributes(complexType.Attributes, complexType.AnyAttribute)
+		}
+
+		extension := complexType.ComplexContent.Extension
+		walkParticle(extension.Content())
+		walkAttributes(extension.Attributes, extension.AnyAttribute)
+
+		if restriction := complexType.SimpleContent.Restriction; restriction.Base != "" {
+			walkAttributes(restriction.Attributes, restriction.AnyAttribute)
+		} else {
+			extension = complexType.SimpleContent.Extension
+			walkAttributes(extension.Attributes, extension.AnyAttribute)
+		}
+	}
+
+	for _, el := range schema.Elements {
+		walkElementType(el)
+	}
+	for _, complexType := range schema.ComplexTypes {
+		walkComplexType(complexType)
+	}
+	for _, group := range schema.Groups {
+		walkParticle(group.Content())
+	}
+	for _, attributeGroup := range schema.AttributeGroups {
+		walkAttributes(attributeGroup.Attributes, attributeGroup.AnyAttribute)
+	}
+}
+
+// Assigns a Go identifier to a schema definition. Identifiers already taken
+// get the last segment of the namespace appended and, if that is still not
+// enough, a number.
+func (g *GoWSDL) declareGoName(definition interface{}, namespace, local string) {
+	name := replaceReservedWords(makePublic(local))
+
+	if g.usedNames[name] {
+		name += namespaceSuffix(namespace)
+	}
+
+	candidate := name
+	for i := 2; g.usedNames[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+
+	g.usedNames[candidate] = true
+	g.goNames[definition] = candidate
+}
+
+// Returns an identifier made out of the last segment of a namespace, ie.
+// "Orders" for "http://example.com/orders" or "urn:example:orders".
+func namespaceSuffix(namespace string) string {
+	segments := strings.FieldsFunc(namespace, func(r rune) bool {
+		return r == '/' || r == ':' || r == '#'
+	})
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		suffix := replaceReservedWords(makePublic(segments[i]))
+		if suffix != "" {
+			return suffix
+		}
+	}
+	return ""
+}
+
+// goTypeName returns the Go identifier of a schema type or of a global
+// element declaring its type inline.
+func (g *GoWSDL) goTypeName(definition interface{}) string {
+	return g.goNames[definition]
+}
+
+// lookupType finds a global simple or complex type by its {namespace}local
+// name. References without namespace fall back to the only type with the
+// same local name, if there is just one.
+func (g *GoWSDL) lookupType(ref string) interface{} {
+	if definition, ok := g.types[ref]; ok {
+		return definition
+	}
+
+	var found interface{}
+	matches := 0
+	forUnqualified(ref, func(local string) {
+		for name, definition := range g.types {
+			if _, l := splitQName(name); l == local {
+				found = definition
+				matches++
+			}
+		}
+	})
+	if matches != 1 {
+		return nil
+	}
+	return found
+}
+
+// lookupElement finds a global element by its {namespace}local name.
+// References without namespace fall back to the only element with the same
+// local name, if there is just one.
+func (g *GoWSDL) lookupElement(ref string) *XSDElement {
+	if element, ok := g.elements[ref]; ok {
+		return element
+	}
+
+	var found *XSDElement
+	matches := 0
+	forUnqualified(ref, func(local string) {
+		for name, element := range g.elements {
+			if _, l := splitQName(name); l == local {
+				found = element
+				matches++
+			}
+		}
+	})
+	if matches != 1 {
+		return nil
+	}
+	return found
+}
+
+// lookupGroup resolves a group reference to the named group it points to.
+// Groups defined in place are returned as they are. References without
+// namespace fall back to the only group with the same local name.
+func (g *GoWSDL) lookupGroup(group *XSDGroup) *XSDGroup {
+	if group.Ref == "" {
+		return group
+	}
+
+	if named, ok := g.groups[group.Ref]; ok {
+		return named
+	}
+
+	var found *XSDGroup
+	matches := 0
+	forUnqualified(group.Ref, func(local string) {
+		for name, named := range g.groups {
+			if _, l := splitQName(name); l == local {
+				found = named
+				matches++
+			}
+		}
+	})
+	if matches != 1 {
+		return nil
+	}
+	return found
+}
+
+// lookupAttributeGroup resolves an attribute group reference to the named
+// attribute group it points to. References without namespace fall back to
+// the only attribute group with the same local name.
+func (g *GoWSDL) lookupAttributeGroup(attributeGroup *XSDAttributeGroup) *XSDAttributeGroup {
+	if attributeGroup.Ref == "" {
+		return attributeGroup
+	}
+
+	if named, ok := g.attributeGroups[attributeGroup.Ref]; ok {
+		return named
+	}
+
+	var found *XSDAttributeGroup
+	matches := 0
+	forUnqualified(attributeGroup.Ref, func(local string) {
+		for name, named := range g.attributeGroups {
+			if _, l := splitQName(name); l == local {
+				found = named
+				matches++
+			}
+		}
+	})
+	if matches != 1 {
+		return nil
+	}
+	return found
+}
+
+// lookupMessage finds a WSDL message by its {namespace}local name.
+func (g *GoWSDL) lookupMessage(ref string) *WSDLMessage {
+	msg, _ := g.wsdlDefinitions["message "+ref].definition.(*WSDLMessage)
+	return msg
+}
+
+// forUnqualified calls match with the local name of a reference without
+// namespace, which may be resolved to the only definition having that local
+// name: schemas without target namespace, such as those included by other
+// schemas, reference the definitions of the schema including them that way.
+// References with a namespace only resolve to definitions of that namespace.
+func forUnqualified(ref string, match func(local string)) {
+	if ns, local := splitQName(ref); ns == "" {
+		match(local)
+	}
+}
+
+// Whether a reference points to a XSD built-in type.
+func isXSDBuiltin(ref string) bool {
+	ns, _ := splitQName(ref)
+	return xsdNamespaces[ns]
+}
+
+// checkReferences reports every type, element, message and binding
+// reference that doesn't resolve to a definition, along with the file it
+// was found in.
+func (g *GoWSDL) checkReferences() {
+	for _, schema := range g.wsdl.Types.Schemas {
+		c := &schemaChecker{g: g, location: schema.location}
+
+		for _, simpleType := range schema.SimpleType {
+			c.checkSimpleType(simpleType)
+		}
+
+		for _, element := range schema.Elements {
+			c.checkElement(element)
+		}
+
+		for _, complexType := range schema.ComplexTypes {
+			c.checkComplexType(complexType)
+		}
+
+		for _, group := range schema.Groups {
+			c.checkGroup(group)
+		}
+
+		for _, attributeGroup := range schema.AttributeGroups {
+			c.checkAttributeGroup(attributeGroup)
+		}
+
+		c.checkAttributes(schema.Attributes)
+	}
+
+	report := func(kind, location, ref, context string) {
+		g.unresolved = append(g.unresolved, fmt.Errorf("%s: unresolved %s reference %q in %s", location, kind, ref, context))
+	}
+
+	for _, msg := range g.wsdl.Messages {
+		location := g.wsdlDefinitions["message "+qname(msg.namespace, msg.Name)].location
+		for _, part := range msg.Parts {
+			if part.Element != "" && g.lookupElement(part.Element) == nil {
+				report("element", location, part.Element, "message "+msg.Name)
+			}
+			if part.Type != "" && !isXSDBuiltin(part.Type) && g.lookupType(part.Type) == nil {
+				report("type", location, part.Type, "message "+msg.Name)
+			}
+		}
+	}
+
+	for _, portType := range g.wsdl.PortTypes {
+		location := g.wsdlDefinitions["portType "+qname(portType.namespace, portType.Name)].location
+		for _, op := range portType.Operations {
+			refs := []string{op.Input.Message, op.Output.Message}
+			for _, fault := range op.Faults {
+				refs = append(refs, fault.Message)
+			}
+
+			for _, ref := range refs {
+				if ref != "" && g.lookupMessage(ref) == nil {
+					report("message", location, ref, "operation "+op.Name)
+				}
+			}
+		}
+	}
+
+	for _, binding := range g.wsdl.Binding {
+		if g.wsdlDefinitions["portType "+binding.Type].definition == nil {
+			location := g.wsdlDefinitions["binding "+qname(binding.namespace, binding.Name)].location
+			report("port type", location, binding.Type, "binding "+binding.Name)
+		}
+	}
+
+	for _, service := range g.wsdl.Service {
+		location := g.wsdlDefinitions["service "+qname(service.namespace, service.Name)].location
+		for _, port := range service.Ports {
+			if g.wsdlDefinitions["binding "+port.Binding].definition == nil {
+				report("binding", location, port.Binding, "port "+port.Name)
+			}
+		}
+	}
+}
+
+// schemaChecker walks the definitions of a schema looking for unresolved
+// references.
+type schemaChecker struct {
+	g        *GoWSDL
+	location string
+}
+
+func (c *schemaChecker) report(kind, ref string) {
+	c.g.unresolved = append(c.g.unresolved, fmt.Errorf("%s: unresolved %s reference %q", c.location, kind, ref))
+}
+
+func (c *schemaChecker) checkType(ref string) {
+	if ref != "" && !isXSDBuiltin(ref) && c.g.lookupType(ref) == nil {
+		c.report("type", ref)
+	}
+}
+
+func (c *schemaChecker) checkElement(element *XSDElement) {
+	c.checkType(element.Type)
+
+	if element.Ref != "" && c.g.lookupElement(element.Ref) == nil {
+		c.report("element", element.Ref)
+	}
+
+	if element.SubstitutionGroup != "" && c.g.lookupElement(element.SubstitutionGroup) == nil {
+		c.report("element", element.SubstitutionGroup)
+	}
+
+	if element.SimpleType != nil {
+		c.checkSimpleType(element.SimpleType)
+	}
+
+	if element.ComplexType != nil {
+		c.checkComplexType(element.ComplexType)
+	}
+}
+
+func (c *schemaChecker) checkSimpleType(simpleType *XSDSimpleType) {
+	c.checkType(simpleType.Restriction.Base)
+
+	if list := simpleType.List; list != nil {
+		c.checkType(list.ItemType)
+		if list.SimpleType != nil {
+			c.checkSimpleType(list.SimpleType)
+		}
+	}
+
+	if union := simpleType.Union; union != nil {
+		for _, member := range strings.Fields(union.MemberTypes) {
+			c.checkType(member)
+		}
+		for _, member := range union.SimpleTypes {
+			c.checkSimpleType(member)
+		}
+	}
+}
+
+func (c *schemaChecker) checkComplexType(complexType *XSDComplexType) {
+	complexContent, simpleContent := &complexType.ComplexContent, &complexType.SimpleContent
+
+	c.checkParticle(complexType.Content())
+	c.checkParticle(complexContent.Extension.Content())
+	c.checkParticle(complexContent.Restriction.Content())
+
+	var attributes []*XSDAttribute
+	attributes = append(attributes, complexType.Attributes...)
+	attributes = append(attributes, complexContent.Extension.Attributes...)
+	attributes = append(attributes, complexContent.Restriction.Attributes...)
+	attributes = append(attributes, simpleContent.Extension.Attributes...)
+	attributes = append(attributes, simpleContent.Restriction.Attributes...)
+	c.checkAttributes(attributes)
+
+	var attributeGroups []*XSDAttributeGroup
+	attributeGroups = append(attributeGroups, complexType.AttributeGroups...)
+	attributeGroups = append(attributeGroups, complexContent.Extension.AttributeGroups...)
+	attributeGroups = append(attributeGroups, complexContent.Restriction.AttributeGroups...)
+	attributeGroups = append(attributeGroups, simpleContent.Extension.AttributeGroups...)
+	attributeGroups = append(attributeGroups, simpleContent.Restriction.AttributeGroups...)
+
+	for _, attributeGroup := range attributeGroups {
+		c.checkAttributeGroup(attributeGroup)
+	}
+
+	c.checkType(complexContent.Extension.Base)
+	c.checkType(complexContent.Restriction.Base)
+	c.checkType(simpleContent.Extension.Base)
+	c.checkType(simpleContent.Restriction.Base)
+}
+
+func (c *schemaChecker) checkAttributes(attributes []*XSDAttribute) {
+	for _, attribute := range attributes {
+		if attribute.Ref != "" && c.g.lookupAttribute(attribute.Ref) == nil {
+			c.report("attribute", attribute.Ref)
+		}
+
+		c.checkType(attribute.Type)
+		if attribute.SimpleType != nil {
+			c.checkSimpleType(attribute.SimpleType)
+		}
+	}
+}
+
+func (c *schemaChecker) checkGroup(group *XSDGroup) {
+	if group.Ref != "" {
+		if c.g.lookupGroup(group) == nil {
+			c.report("group", group.Ref)
+		}
+		return
+	}
+
+	c.checkParticle(group.Content())
+}
+
+func (c *schemaChecker) checkParticle(p *XSDParticle) {
+	if p == nil {
+		return
+	}
+
+	switch p.Kind {
+	case "element":
+		c.checkElement(p.Element)
+	case "group":
+		c.checkGroup(p.Group)
+	}
+
+	for _, child := range p.Particles {
+		c.checkParticle(child)
+	}
+}
+
+func (c *schemaChecker) checkAttributeGroup(attributeGroup *XSDAttributeGroup) {
+	if attributeGroup.Ref != "" {
+		if c.g.lookupAttributeGroup(attributeGroup) == nil {
+			c.report("attribute group", attributeGroup.Ref)
+		}
+		return
+	}
+
+	c.checkAttributes(attributeGroup.Attributes)
+
+	for _, nested := range attributeGroup.AttributeGroups {
+		c.checkAttributeGroup(nested)
+	}
+}
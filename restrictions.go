@@ -0,0 +1,318 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"reflect"
+	"strings"
+)
+
+// indexRestrictions restates the complex types derived by restriction as
+// types declaring their content themselves, which they are generated as:
+// the content model they restate, or the one of their base if they restate
+// none, and the attributes of their base less those they prohibit, with
+// those they redeclare. The value of types with simple content restricted
+// by facets gets a simple type of its own.
+func (g *GoWSDL) indexRestrictions() {
+	g.restatedTypes = make(map[*XSDComplexType]*XSDComplexType)
+
+	schemas := make(map[*XSDComplexType]*XSDSchema)
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, complexType := range schema.ComplexTypes {
+			schemas[complexType] = schema
+		}
+	}
+
+	// Bases are restricted first, guarding against cycles.
+	done := make(map[*XSDComplexType]bool)
+	var restrict func(schema *XSDSchema, name string, complexType *XSDComplexType)
+	restrict = func(schema *XSDSchema, name string, complexType *XSDComplexType) {
+		if done[complexType] {
+			return
+		}
+		done[complexType] = true
+
+		if restriction := &complexType.ComplexContent.Restriction; restriction.Base != "" {
+			base, _ := g.lookupType(restriction.Base).(*XSDComplexType)
+			if base != nil {
+				restrict(schemas[base], base.Name, base)
+			}
+			g.restrictComplexContent(complexType, restriction, base)
+		}
+
+		if restriction := &complexType.SimpleContent.Restriction; restriction.Base != "" {
+			base, _ := g.lookupType(restriction.Base).(*XSDComplexType)
+			if base != nil {
+				restrict(schemas[base], base.Name, base)
+			}
+			g.restrictSimpleContent(schema, name, complexType, restriction, base)
+		}
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, complexType := range schema.ComplexTypes {
+			restrict(schema, complexType.Name, complexType)
+		}
+		for _, element := range schema.Elements {
+			if element.ComplexType != nil {
+				restrict(schema, element.Name, element.ComplexType)
+			}
+		}
+		walkDeclarations(schema, func(element *XSDElement) {
+			if element.ComplexType != nil {
+				restrict(schema, element.Name, element.ComplexType)
+			}
+		}, nil, nil)
+	}
+}
+
+// restated returns the type a complex type is generated as: the one
+// restating it for types derived by restriction, the type itself otherwise.
+func (g *GoWSDL) restated(complexType *XSDComplexType) *XSDComplexType {
+	if restated, ok := g.restatedTypes[complexType]; ok {
+		return restated
+	}
+	return complexType
+}
+
+func (g *GoWSDL) restrictComplexContent(complexType *XSDComplexType, restriction *XSDComplexRestriction, base *XSDComplexType) {
+	content := restriction.Content()
+	attributes := restriction.Attributes
+	if content == nil {
+		if content = soapArrayContent(restriction); content != nil {
+			// The item elements stand for the soapenc:arrayType attribute.
+			attributes = nil
+			for _, attribute := range restriction.Attributes {
+				if attribute.ArrayType == "" {
+					attributes = append(attributes, attribute)
+				}
+			}
+		}
+	}
+	if content == nil && base != nil {
+		content = g.inheritedContent(base)
+	}
+
+	restated := &XSDComplexType{Abstract: complexType.Abstract, Name: complexType.Name, Mixed: complexType.Mixed}
+	if content != nil {
+		switch content.Kind {
+		case "sequence":
+			restated.Sequence = content
+		case "choice":
+			restated.Choice = content
+		case "all":
+			restated.All = content
+		default:
+			restated.Group = content
+		}
+	}
+
+	restated.Attributes, restated.AnyAttribute = g.restrictAttributes(base, attributes, restriction.AttributeGroups, restriction.AnyAttribute)
+	g.restatedTypes[complexType] = restated
+}
+
+func (g *GoWSDL) restrictSimpleContent(schema *XSDSchema, name string, complexType *XSDComplexType, restriction *XSDSimpleRestriction, base *XSDComplexType) {
+	valueType := restriction.Base
+	if base != nil {
+		valueType = g.simpleContentType(base)
+	}
+
+	restated := restriction.XSDRestriction
+	restated.Base = ""
+	if valueType != "" && !reflect.DeepEqual(restated, XSDRestriction{}) {
+		valueType = g.declareValueType(schema, name, valueType, restriction.XSDRestriction)
+	}
+
+	extension := XSDExtension{Base: valueType}
+	extension.Attributes, extension.AnyAttribute = g.restrictAttributes(base, restriction.Attributes, restriction.AttributeGroups, restriction.AnyAttribute)
+	g.restatedTypes[complexType] = &XSDComplexType{
+		Abstract:      complexType.Abstract,
+		Name:          complexType.Name,
+		SimpleContent: XSDSimpleContent{Extension: extension},
+	}
+}
+
+// Returns the attributes of a type restricting base, given those the
+// restriction declares: the attributes of the base, replaced by the ones
+// redeclared, followed by the ones added, less the ones prohibited. The
+// attribute wildcard of the base is kept unless the restriction declares
+// one.
+func (g *GoWSDL) restrictAttributes(base *XSDComplexType, attributes []*XSDAttribute, attributeGroups []*XSDAttributeGroup, anyAttribute *XSDWildcard) ([]*XSDAttribute, *XSDWildcard) {
+	declared := g.expandAttributes(attributes, attributeGroups)
+	redeclared := make(map[string]*XSDAttribute, len(declared))
+	for _, attribute := range declared {
+		redeclared[attributeName(attribute)] = attribute
+	}
+
+	var restricted []*XSDAttribute
+	keep := func(attribute *XSDAttribute) {
+		if attribute.Use != "prohibited" {
+			restricted = append(restricted, attribute)
+		}
+	}
+
+	var wildcard *XSDWildcard
+	if base != nil {
+		for _, attribute := range g.inheritedAttributes(base) {
+			name := attributeName(attribute)
+			if redeclaration, ok := redeclared[name]; ok {
+				attribute = redeclaration
+				delete(redeclared, name)
+			}
+			keep(attribute)
+		}
+		wildcard = g.inheritedWildcard(base)
+	}
+	for _, attribute := range declared {
+		if _, ok := redeclared[attributeName(attribute)]; ok {
+			keep(attribute)
+		}
+	}
+
+	if declaredWildcard := g.findAttributeWildcard(anyAttribute, attributeGroups); declaredWildcard != nil {
+		wildcard = declaredWildcard
+	}
+	return restricted, wildcard
+}
+
+// Returns the local name of an attribute declaration or reference.
+func attributeName(attribute *XSDAttribute) string {
+	if attribute.Ref != "" {
+		_, local := splitQName(attribute.Ref)
+		return local
+	}
+	return attribute.Name
+}
+
+// Returns attributes followed by those of attribute groups.
+func (g *GoWSDL) expandAttributes(attributes []*XSDAttribute, attributeGroups []*XSDAttributeGroup) []*XSDAttribute {
+	expanded := append([]*XSDAttribute(nil), attributes...)
+	for _, attributeGroup := range attributeGroups {
+		if resolved := g.lookupAttributeGroup(attributeGroup); resolved != nil {
+			expanded = append(expanded, g.expandAttributes(resolved.Attributes, resolved.AttributeGroups)...)
+		}
+	}
+	return expanded
+}
+
+// Walks a complex type and the types it extends, as restated, derived types
+// first.
+func (g *GoWSDL) walkExtensions(complexType *XSDComplexType, visit func(complexType *XSDComplexType, extension *XSDExtension)) {
+	seen := map[*XSDComplexType]bool{}
+	for complexType != nil && !seen[complexType] {
+		seen[complexType] = true
+		complexType = g.restated(complexType)
+
+		extension := &complexType.ComplexContent.Extension
+		if extension.Base == "" {
+			extension = &complexType.SimpleContent.Extension
+		}
+		if extension.Base == "" {
+			extension = nil
+		}
+		visit(complexType, extension)
+
+		if extension == nil {
+			return
+		}
+		complexType, _ = g.lookupType(extension.Base).(*XSDComplexType)
+	}
+}
+
+// Returns the attributes of a complex type, including those it inherits by
+// extension, which come first.
+func (g *GoWSDL) inheritedAttributes(complexType *XSDComplexType) []*XSDAttribute {
+	var attributes []*XSDAttribute
+	g.walkExtensions(complexType, func(complexType *XSDComplexType, extension *XSDExtension) {
+		declared := g.expandAttributes(complexType.Attributes, complexType.AttributeGroups)
+		if extension != nil {
+			declared = g.expandAttributes(extension.Attributes, extension.AttributeGroups)
+		}
+		attributes = append(declared, attributes...)
+	})
+	return attributes
+}
+
+// Returns the attribute wildcard of a complex type or of the closest type
+// it extends declaring one.
+func (g *GoWSDL) inheritedWildcard(complexType *XSDComplexType) *XSDWildcard {
+	var wildcard *XSDWildcard
+	g.walkExtensions(complexType, func(complexType *XSDComplexType, _ *XSDExtension) {
+		if wildcard == nil {
+			wildcard = g.attributeWildcard(complexType)
+		}
+	})
+	return wildcard
+}
+
+// Returns the content model of a complex type, including the content it
+// inherits by extension, which comes first.
+func (g *GoWSDL) inheritedContent(complexType *XSDComplexType) *XSDParticle {
+	var particles []*XSDParticle
+	g.walkExtensions(complexType, func(complexType *XSDComplexType, extension *XSDExtension) {
+		content := complexType.Content()
+		if extension != nil {
+			content = extension.Content()
+		}
+		if content != nil {
+			particles = append([]*XSDParticle{content}, particles...)
+		}
+	})
+
+	switch len(particles) {
+	case 0:
+		return nil
+	case 1:
+		return particles[0]
+	}
+	return &XSDParticle{Kind: "sequence", Particles: particles}
+}
+
+// Returns the type of the value of a complex type with simple content,
+// following the types it extends.
+func (g *GoWSDL) simpleContentType(complexType *XSDComplexType) string {
+	var valueType string
+	g.walkExtensions(complexType, func(_ *XSDComplexType, extension *XSDExtension) {
+		if extension != nil {
+			valueType = extension.Base
+		}
+	})
+	if _, ok := g.lookupType(valueType).(*XSDComplexType); ok {
+		return ""
+	}
+	return valueType
+}
+
+// Declares the simple type of the value of a complex type restricting its
+// simple content with facets, named after the complex type, and returns a
+// reference to it.
+func (g *GoWSDL) declareValueType(schema *XSDSchema, typeName, base string, restriction XSDRestriction) string {
+	restriction.Base = base
+	return g.declareSimpleType(schema, typeName+"Value", &XSDSimpleType{Restriction: restriction})
+}
+
+// Returns the content of a SOAP-encoded array restricting soapenc:Array
+// without restating it: item elements of the type given by wsdl:arrayType.
+func soapArrayContent(restriction *XSDComplexRestriction) *XSDParticle {
+	if restriction.Base != qname(soapEncNamespace, "Array") {
+		return nil
+	}
+
+	for _, attribute := range restriction.Attributes {
+		itemType := attribute.ArrayType
+		if i := strings.Index(itemType, "["); i >= 0 {
+			itemType = itemType[:i]
+		}
+		if itemType == "" {
+			continue
+		}
+
+		item := &XSDElement{Name: "item", Type: itemType, MinOccurs: "0", MaxOccurs: "unbounded"}
+		return &XSDParticle{Kind: "sequence", Particles: []*XSDParticle{
+			{Kind: "element", MinOccurs: item.MinOccurs, MaxOccurs: item.MaxOccurs, Element: item},
+		}}
+	}
+	return nil
+}
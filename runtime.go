@@ -0,0 +1,241 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Packages the runtime and the generated code may use, imported by the
+// header when they do.
+var runtimeImports = []string{
+	"bytes",
+	"crypto/tls",
+	"encoding",
+	"encoding/base64",
+	"encoding/hex",
+	"encoding/xml",
+	"fmt",
+	"io/ioutil",
+	"log",
+	"math/big",
+	"net",
+	"net/http",
+	"reflect",
+	"regexp",
+	"strconv",
+	"strings",
+	"time",
+}
+
+// isRuntimeImport tells whether a package is one of runtimeImports.
+func isRuntimeImport(importPath string) bool {
+	for _, runtimeImport := range runtimeImports {
+		if runtimeImport == importPath {
+			return true
+		}
+	}
+	return false
+}
+
+// Declarations of the runtime always emitted: the SOAP client, its
+// envelope and the errors its Call method returns.
+var runtimeRoots = []string{"SOAPClient", "NewSOAPClient", "NewSOAP12Client", "SOAPHeader", "ValidationError", "ValidationErrors"}
+
+// runtimeDecl is a top-level declaration of the runtime, with its doc
+// comment.
+type runtimeDecl struct {
+	code string
+	// Names it declares, or the name of a method.
+	names []string
+	// Type of the receiver of a method, or of the result of an exported
+	// function, emitted with the type.
+	receiver string
+}
+
+// pruneRuntime returns the declarations of the runtime used by the
+// generated code, in their order: those of runtimeRoots, those the code or
+// other emitted declarations refer to, and the methods and constructors of
+// emitted types that are exported or referred to. Sections of the runtime, such as the
+// decoding of groups or the XSD types of dates, are thus only emitted when
+// needed.
+func pruneRuntime(runtime string, code ...[]byte) (string, error) {
+	decls, err := runtimeDecls(runtime)
+	if err != nil {
+		return "", err
+	}
+
+	used := make(map[string]bool)
+	for _, name := range runtimeRoots {
+		used[name] = true
+	}
+	for _, c := range code {
+		identifiers(used, c)
+	}
+
+	emitted := make([]bool, len(decls))
+	for changed := true; changed; {
+		changed = false
+		for i, decl := range decls {
+			if emitted[i] || !decl.used(used) {
+				continue
+			}
+			emitted[i], changed = true, true
+			identifiers(used, []byte(decl.code))
+		}
+	}
+
+	var pruned []string
+	for i, decl := range decls {
+		if emitted[i] {
+			pruned = append(pruned, decl.code)
+		}
+	}
+	return "\n" + strings.Join(pruned, "\n\n") + "\n", nil
+}
+
+// Tells whether a declaration is used by code referring to the given
+// identifiers. Methods are used when their type is, unless they are
+// unexported and not referred to.
+func (d *runtimeDecl) used(identifiers map[string]bool) bool {
+	if d.receiver != "" {
+		return identifiers[d.receiver] && (ast.IsExported(d.names[0]) || identifiers[d.names[0]])
+	}
+
+	for _, name := range d.names {
+		if identifiers[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// Splits the runtime into its top-level declarations.
+func runtimeDecls(runtime string) ([]*runtimeDecl, error) {
+	src := "package runtime\n" + runtime
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "runtime.go", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := func(pos token.Pos) int {
+		return fset.Position(pos).Offset
+	}
+
+	var decls []*runtimeDecl
+	for _, d := range file.Decls {
+		start, doc := d.Pos(), (*ast.CommentGroup)(nil)
+		decl := new(runtimeDecl)
+
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			doc = d.Doc
+			decl.names = []string{d.Name.Name}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				decl.receiver = receiverName(d.Recv.List[0].Type)
+			} else if results := d.Type.Results; d.Name.IsExported() && results != nil && len(results.List) > 0 {
+				// Constructors come with their type.
+				decl.receiver = receiverName(results.List[0].Type)
+			}
+		case *ast.GenDecl:
+			doc = d.Doc
+			for _, spec := range d.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					decl.names = append(decl.names, spec.Name.Name)
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						decl.names = append(decl.names, name.Name)
+					}
+				}
+			}
+		}
+		if doc != nil {
+			start = doc.Pos()
+		}
+
+		decl.code = src[offset(start):offset(d.End())]
+		decls = append(decls, decl)
+	}
+	return decls, nil
+}
+
+// Returns the name of the type of a receiver, such as T for *T or T[E].
+func receiverName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// Adds the identifiers of Go code to used.
+func identifiers(used map[string]bool, code []byte) {
+	fset := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fset.AddFile("", fset.Base(), len(code)), code, nil, 0)
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return
+		}
+		if tok == token.IDENT {
+			used[lit] = true
+		}
+	}
+}
+
+// usedImports returns the packages of runtimeImports Go code refers to,
+// quoted and in order.
+func usedImports(code ...[]byte) ([]string, error) {
+	packages := make(map[string]string)
+	for _, importPath := range runtimeImports {
+		packages[path.Base(importPath)] = importPath
+	}
+
+	src := []byte("package imports\n")
+	for _, c := range code {
+		src = append(src, c...)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "imports.go", src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Selectors of identifiers declared nowhere in the code are qualified
+	// identifiers of packages.
+	seen := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil && packages[x.Name] != "" {
+				seen[packages[x.Name]] = true
+			}
+		}
+		return true
+	})
+
+	var imports []string
+	for importPath := range seen {
+		imports = append(imports, strconv.Quote(importPath))
+	}
+	sort.Strings(imports)
+	return imports, nil
+}
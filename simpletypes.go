@@ -0,0 +1,93 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"strconv"
+	"strings"
+)
+
+// valueType returns the Go type of a value of a schema type, which is not a
+// pointer even for generated types.
+func valueType(goType string) string {
+	return strings.TrimPrefix(goType, "*")
+}
+
+// listItemType returns the Go type of the items of an xs:list.
+func (g *GoWSDL) listItemType(list *XSDList) string {
+	if list.ItemType == "" && list.SimpleType != nil {
+		return valueType(g.toGoType(list.SimpleType.Restriction.Base))
+	}
+	return valueType(g.toGoType(list.ItemType))
+}
+
+// unionMemberTypes returns the Go types of the members of an xs:union in the
+// order they are tried when decoding: referenced ones first, then those
+// declared inline.
+func (g *GoWSDL) unionMemberTypes(union *XSDUnion) []string {
+	var members []string
+	for _, member := range strings.Fields(union.MemberTypes) {
+		members = append(members, valueType(g.toGoType(member)))
+	}
+	for _, member := range union.SimpleTypes {
+		members = append(members, valueType(g.toGoType(member.Restriction.Base)))
+	}
+	return members
+}
+
+// underlyingType returns the Go type a restricted simple type is based on,
+// following restrictions of other simple types, or nothing for lists and
+// unions.
+func (g *GoWSDL) underlyingType(simpleType *XSDSimpleType) string {
+	seen := map[*XSDSimpleType]bool{}
+	for !seen[simpleType] {
+		seen[simpleType] = true
+		if simpleType.List != nil || simpleType.Union != nil {
+			return ""
+		}
+
+		base, ok := g.lookupType(simpleType.Restriction.Base).(*XSDSimpleType)
+		if !ok {
+			return valueType(g.toGoType(simpleType.Restriction.Base))
+		}
+		simpleType = base
+	}
+	return ""
+}
+
+// enumValue returns an enumeration value of a simple type as a Go constant
+// of its underlying type, or nothing if it can't be declared as one.
+func (g *GoWSDL) enumValue(simpleType *XSDSimpleType, value string) string {
+	trimmed := strings.TrimSpace(value)
+
+	var err error
+	switch g.underlyingType(simpleType) {
+	case "string":
+		return strconv.Quote(value)
+	case "bool":
+		switch trimmed {
+		case "true", "1":
+			return "true"
+		case "false", "0":
+			return "false"
+		}
+		return ""
+	case "int", "int8", "int16", "int32", "int64":
+		_, err = strconv.ParseInt(trimmed, 10, 64)
+	case "uint", "byte", "uint16", "uint32", "uint64":
+		_, err = strconv.ParseUint(trimmed, 10, 64)
+	case "float32", "float64":
+		if _, err = strconv.ParseFloat(trimmed, 64); strings.Trim(trimmed, "+-.0123456789eE") != "" {
+			return ""
+		}
+	default:
+		return ""
+	}
+
+	if err != nil {
+		return ""
+	}
+	return trimmed
+}
@@ -11,8 +11,15 @@ func dialTimeout(network, addr string) (net.Conn, error) {
 	return net.DialTimeout(network, addr, timeout)
 }
 
+const (
+	soap11Namespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12Namespace = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// SOAPEnvelope is shared by SOAP 1.1 and SOAP 1.2; XMLName carries the
+// namespace of the protocol in use.
 type SOAPEnvelope struct {
-	XMLName xml.Name ` + "`" + `xml:"http://schemas.xmlsoap.org/soap/envelope/ Envelope"` + "`" + `
+	XMLName xml.Name
 
 	Body SOAPBody
 }
@@ -24,9 +31,10 @@ type SOAPHeader struct {
 }
 
 type SOAPBody struct {
-	XMLName xml.Name ` + "`" + `xml:"http://schemas.xmlsoap.org/soap/envelope/ Body"` + "`" + `
+	XMLName xml.Name
 
 	Fault   *SOAPFault ` + "`" + `xml:",omitempty"` + "`" + `
+	Fault12 *SOAP12Fault ` + "`" + `xml:",omitempty"` + "`" + `
 	Content interface{} ` + "`" + `xml:",omitempty"` + "`" + `
 }
 
@@ -39,6 +47,34 @@ type SOAPFault struct {
 	Detail string ` + "`" + `xml:"detail,omitempty"` + "`" + `
 }
 
+type SOAP12Fault struct {
+	XMLName xml.Name ` + "`" + `xml:"http://www.w3.org/2003/05/soap-envelope Fault"` + "`" + `
+
+	Code   SOAP12FaultCode   ` + "`" + `xml:"http://www.w3.org/2003/05/soap-envelope Code"` + "`" + `
+	Reason SOAP12FaultReason ` + "`" + `xml:"http://www.w3.org/2003/05/soap-envelope Reason"` + "`" + `
+	Node   string            ` + "`" + `xml:"http://www.w3.org/2003/05/soap-envelope Node,omitempty"` + "`" + `
+	Role   string            ` + "`" + `xml:"http://www.w3.org/2003/05/soap-envelope Role,omitempty"` + "`" + `
+	Detail *SOAP12FaultDetail ` + "`" + `xml:"http://www.w3.org/2003/05/soap-envelope Detail,omitempty"` + "`" + `
+}
+
+type SOAP12FaultCode struct {
+	Value   string           ` + "`" + `xml:"http://www.w3.org/2003/05/soap-envelope Value"` + "`" + `
+	Subcode *SOAP12FaultCode ` + "`" + `xml:"http://www.w3.org/2003/05/soap-envelope Subcode,omitempty"` + "`" + `
+}
+
+type SOAP12FaultReason struct {
+	Text []SOAP12FaultText ` + "`" + `xml:"http://www.w3.org/2003/05/soap-envelope Text"` + "`" + `
+}
+
+type SOAP12FaultText struct {
+	Lang  string ` + "`" + `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"` + "`" + `
+	Value string ` + "`" + `xml:",chardata"` + "`" + `
+}
+
+type SOAP12FaultDetail struct {
+	Content string ` + "`" + `xml:",innerxml"` + "`" + `
+}
+
 type BasicAuth struct {
 	Login string
 	Password string
@@ -48,6 +84,8 @@ type SOAPClient struct {
 	url string
 	tls bool
 	auth *BasicAuth
+	soap12 bool
+	validate bool
 }
 
 func (b *SOAPBody) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
@@ -75,7 +113,7 @@ Loop:
 		case xml.StartElement:
 			if consumed {
 				return xml.UnmarshalError("Found multiple elements inside SOAP body; not wrapped-document/literal WS-I compliant")
-			} else if se.Name.Space == "http://schemas.xmlsoap.org/soap/envelope/" && se.Name.Local == "Fault" {
+			} else if se.Name.Space == soap11Namespace && se.Name.Local == "Fault" {
 				b.Fault = &SOAPFault{}
 				b.Content = nil
 
@@ -84,6 +122,16 @@ Loop:
 					return err
 				}
 
+				consumed = true
+			} else if se.Name.Space == soap12Namespace && se.Name.Local == "Fault" {
+				b.Fault12 = &SOAP12Fault{}
+				b.Content = nil
+
+				err = d.DecodeElement(b.Fault12, &se)
+				if err != nil {
+					return err
+				}
+
 				consumed = true
 			} else {
 				if err = d.DecodeElement(b.Content, &se); err != nil {
@@ -104,79 +152,2416 @@ func (f *SOAPFault) Error() string {
 	return f.String
 }
 
-func NewSOAPClient(url string, tls bool, auth *BasicAuth) *SOAPClient {
-	return &SOAPClient{
-		url: url,
-		tls: tls,
-		auth: auth,
+func (f *SOAP12Fault) Error() string {
+	for _, text := range f.Reason.Text {
+		if text.Value != "" {
+			return text.Value
+		}
+	}
+
+	code := f.Code.Value
+	for sub := f.Code.Subcode; sub != nil; sub = sub.Subcode {
+		code += "/" + sub.Value
 	}
+	return code
 }
 
-func (s *SOAPClient) Call(soapAction string, request, response interface{}) error {
-	envelope := SOAPEnvelope{
-	//Header:        SoapHeader{},
+// unmarshalGroup decodes an element of a repeated sequence or choice into
+// the occurrence it belongs to, starting a new occurrence when the element
+// can't follow the ones already decoded. group points to a slice of the
+// structs generated for the sequence or choice.
+func unmarshalGroup(d *xml.Decoder, start xml.StartElement, group interface{}, choice bool) error {
+	list := reflect.ValueOf(group).Elem()
+	itemType := list.Type().Elem()
+
+	field := groupField(itemType, start.Name)
+	if field < 0 {
+		return d.Skip()
 	}
 
-	envelope.Body.Content = request
-	buffer := new(bytes.Buffer)
+	n := list.Len()
+	if n > 0 && isWildcardField(itemType.Field(field)) {
+		field = itemWildcard(list.Index(n - 1))
+	}
+	if n == 0 || startsOccurrence(list.Index(n-1), field, choice) {
+		list.Set(reflect.Append(list, reflect.New(itemType).Elem()))
+		n++
+	}
 
-	encoder := xml.NewEncoder(buffer)
-	//encoder.Indent("  ", "    ")
+	return decodeGroupElement(d, start, list.Index(n-1).Field(field))
+}
 
-	err := encoder.Encode(envelope)
-	if err == nil {
-		err = encoder.Flush()
+// marshalGroup encodes the occurrences of a repeated sequence or choice one
+// after the other, without any enclosing element.
+func marshalGroup(e *xml.Encoder, group interface{}) error {
+	list := reflect.ValueOf(group)
+	for i := 0; i < list.Len(); i++ {
+		if err := marshalGroupItem(e, list.Index(i).Addr().Interface()); err != nil {
+			return err
+		}
 	}
 
-	log.Println(buffer.String())
-	if err != nil {
-		return err
+	return nil
+}
+
+// unmarshalGroupItem decodes an element into the field of item, a pointer to
+// a group struct, receiving it.
+func unmarshalGroupItem(d *xml.Decoder, start xml.StartElement, item interface{}) error {
+	v := reflect.ValueOf(item).Elem()
+
+	field := groupField(v.Type(), start.Name)
+	if field < 0 {
+		return d.Skip()
+	}
+	if isWildcardField(v.Type().Field(field)) {
+		field = itemWildcard(v)
 	}
 
-	req, err := http.NewRequest("POST", s.url, buffer)
-	if s.auth != nil {
-		req.SetBasicAuth(s.auth.Login, s.auth.Password)
+	return decodeGroupElement(d, start, v.Field(field))
+}
+
+// marshalGroupItem encodes the fields of item, a pointer to a group struct,
+// without any enclosing element.
+func marshalGroupItem(e *xml.Encoder, item interface{}) error {
+	v := reflect.ValueOf(item).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name, options := groupTag(v.Type().Field(i))
+		value := v.Field(i)
+		if name.Local == "-" || isEmptyGroupValue(value, options) {
+			continue
+		}
+
+		var err error
+		if group, ok := value.Interface().(xml.Marshaler); ok && strings.Contains(options, "any") {
+			err = group.MarshalXML(e, xml.StartElement{})
+		} else {
+			err = e.EncodeElement(value.Interface(), xml.StartElement{Name: name})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isEmptyGroupValue(v reflect.Value, options string) bool {
+	switch v.Kind() {
+	case reflect.Slice:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Struct:
+		return v.IsZero()
 	}
+	return strings.Contains(options, "omitempty") && v.IsZero()
+}
+
+// Alternatives of the choices generated as sealed interfaces, by interface.
+var choiceAlternatives = map[reflect.Type][]reflect.Type{}
 
-	req.Header.Add("Content-Type", "text/xml; charset=\"utf-8\"")
-	if soapAction != "" {
-		req.Header.Add("SOAPAction", soapAction)
+// registerChoice records the alternatives of a choice interface, given a nil
+// pointer to the interface and one to each alternative.
+func registerChoice(choice interface{}, alternatives ...interface{}) {
+	t := reflect.TypeOf(choice).Elem()
+	for _, alternative := range alternatives {
+		choiceAlternatives[t] = append(choiceAlternatives[t], reflect.TypeOf(alternative))
 	}
+}
 
-	req.Header.Set("User-Agent", "gowsdl/0.1")
-	req.Close = true
+// validateChoice reports an error unless exactly one alternative of choice
+// is set, or none if the choice is optional, and validates the alternative
+// set.
+func validateChoice(choice interface{}, optional bool) error {
+	v := reflect.ValueOf(choice)
 
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: s.tls,
-		},
-		Dial: dialTimeout,
+	var errs ValidationErrors
+	var set []string
+	for i := 0; i < v.NumField(); i++ {
+		if isEmptyGroupValue(v.Field(i), "omitempty") {
+			continue
+		}
+		set = append(set, v.Type().Field(i).Name)
+
+		path, options := groupTag(v.Type().Field(i))
+		if strings.Contains(options, "any") {
+			path.Local = ""
+		}
+		errs.check(path.Local, v.Field(i).Interface())
 	}
 
-	client := &http.Client{Transport: tr}
-	res, err := client.Do(req)
-	if err != nil {
+	if len(set) > 1 {
+		errs.add("", fmt.Errorf("%s: only one of %s may be set", v.Type().Name(), strings.Join(set, ", ")))
+	}
+	if len(set) == 0 && !optional {
+		errs.add("", fmt.Errorf("%s: one alternative must be set", v.Type().Name()))
+	}
+	return errs.err()
+}
+
+// Decodes an element into v, the field of a group struct receiving it. Choice
+// interfaces get the alternative accepting the element.
+func decodeGroupElement(d *xml.Decoder, start xml.StartElement, v reflect.Value) error {
+	if v.Kind() != reflect.Interface {
+		return d.DecodeElement(v.Addr().Interface(), &start)
+	}
+
+	if !v.IsNil() {
+		if !groupAccepts(v.Elem().Type(), start.Name) {
+			return xml.UnmarshalError("more than one alternative of " + v.Type().Name())
+		}
+		return unmarshalGroupItem(d, start, v.Elem().Interface())
+	}
+
+	for _, alternative := range choiceAlternatives[v.Type()] {
+		if groupAccepts(alternative, start.Name) {
+			value := reflect.New(alternative.Elem())
+			v.Set(value)
+			return unmarshalGroupItem(d, start, value.Interface())
+		}
+	}
+
+	return d.Skip()
+}
+
+// unmarshalGroups decodes start into v, which must not implement
+// xml.Unmarshaler itself, handing the child elements of repeated sequences
+// and choices to the fields accepting them, and those of no field of v to
+// the wildcards among groups. encoding/xml alone would hand them all to the
+// first one.
+func unmarshalGroups(d *xml.Decoder, start xml.StartElement, v interface{}, groups ...interface{}) error {
+	r := &groupReader{d: d, start: &start, fields: reflect.TypeOf(v).Elem()}
+	for _, group := range groups {
+		if wildcard, ok := group.(*anyField); ok {
+			r.wildcards = append(r.wildcards, wildcard)
+		} else {
+			r.groups = append(r.groups, group)
+		}
+	}
+	return xml.NewTokenDecoder(r).Decode(v)
+}
+
+// anyField is a field holding the elements matched by a wildcard, handed to
+// unmarshalGroups along with the other wildcards of a struct, in order.
+type anyField struct {
+	elements *[]AnyElement
+	// max is -1 when unbounded.
+	max int
+	// Elements declared between the previous wildcard and this one, after
+	// which the previous wildcards take no more elements.
+	after []string
+	// Namespaces the wildcard allows, or doesn't when other is set. Wildcards
+	// allowing every namespace have none.
+	other      bool
+	namespaces []string
+}
+
+func (f *anyField) allows(space string) bool {
+	return f.namespaces == nil && !f.other || containsString(f.namespaces, space) != f.other
+}
+
+func (f *anyField) full() bool {
+	return f.max >= 0 && len(*f.elements) >= f.max
+}
+
+// groupReader passes on the tokens of an element except for the children
+// decoded into a group or a wildcard.
+type groupReader struct {
+	d         *xml.Decoder
+	start     *xml.StartElement
+	depth     int
+	groups    []interface{}
+	fields    reflect.Type
+	wildcards []*anyField
+	// The first wildcard that may take the next element.
+	wildcard int
+}
+
+func (r *groupReader) Token() (xml.Token, error) {
+	if r.start != nil {
+		start := *r.start
+		r.start = nil
+		return start, nil
+	}
+
+	for {
+		token, err := r.d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if r.depth == 0 {
+				r.follow(t.Name)
+				if group := r.groupFor(t.Name); group.IsValid() {
+					if err := decodeGroupElement(r.d, t, group); err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+			r.depth++
+		case xml.EndElement:
+			r.depth--
+		}
+
+		return token, nil
+	}
+}
+
+func (r *groupReader) groupFor(name xml.Name) reflect.Value {
+	for _, group := range r.groups {
+		v := reflect.ValueOf(group).Elem()
+		if groupAccepts(v.Type(), name) {
+			return v
+		}
+	}
+
+	if len(r.wildcards) == 0 || structField(r.fields, name) {
+		return reflect.Value{}
+	}
+	return reflect.ValueOf(r.wildcardFor(name).elements).Elem()
+}
+
+// Moves past the wildcards declared before an element.
+func (r *groupReader) follow(name xml.Name) {
+	for i := r.wildcard + 1; i < len(r.wildcards); i++ {
+		if containsString(r.wildcards[i].after, name.Local) {
+			r.wildcard = i
+		}
+	}
+}
+
+// Returns the wildcard taking an element no other field takes: the first
+// one from the last used that allows its namespace and may take one more,
+// else the first allowing its namespace, which validation will report.
+func (r *groupReader) wildcardFor(name xml.Name) *anyField {
+	chosen := -1
+	for i := len(r.wildcards) - 1; i >= r.wildcard; i-- {
+		if w := r.wildcards[i]; w.allows(name.Space) && (!w.full() || chosen < 0 || r.wildcards[chosen].full()) {
+			chosen = i
+		}
+	}
+
+	if chosen >= 0 {
+		r.wildcard = chosen
+	}
+	return r.wildcards[r.wildcard]
+}
+
+// Tells whether a struct has a field, other than wildcards and groups,
+// receiving the given element.
+func structField(t reflect.Type, name xml.Name) bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			if base := field.Type; base.Kind() == reflect.Ptr && base.Elem().Kind() == reflect.Struct && structField(base.Elem(), name) {
+				return true
+			}
+			continue
+		}
+		if field.Name == "XMLName" {
+			continue
+		}
+
+		fieldName, options := groupTag(field)
+		if options == "" || options == ",omitempty" {
+			if fieldName.Local == name.Local && (fieldName.Space == "" || fieldName.Space == name.Space) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Tells whether a group struct, a list of them or a choice interface
+// receives the given element.
+func groupAccepts(t reflect.Type, name xml.Name) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		return groupAccepts(t.Elem(), name)
+	case reflect.Interface:
+		for _, alternative := range choiceAlternatives[t] {
+			if groupAccepts(alternative, name) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		if t == anyElementType {
+			return true
+		}
+		if _, ok := substitutes[t]; ok {
+			_, ok = substituteFor(t, name)
+			return ok
+		}
+		return groupField(t, name) >= 0
+	}
+	return false
+}
+
+// Returns the field of a group struct receiving the given element, which
+// may be a nested group accepting it, or the field holding the elements of
+// the first wildcard if no other does.
+func groupField(itemType reflect.Type, name xml.Name) int {
+	wildcard := -1
+	for i := 0; i < itemType.NumField(); i++ {
+		field := itemType.Field(i)
+		fieldName, options := groupTag(field)
+		if isWildcardField(field) {
+			if wildcard < 0 {
+				wildcard = i
+			}
+		} else if strings.Contains(options, "any") {
+			if groupAccepts(field.Type, name) {
+				return i
+			}
+		} else if fieldName.Local == name.Local && (fieldName.Space == "" || fieldName.Space == name.Space) {
+			return i
+		}
+	}
+
+	return wildcard
+}
+
+func isWildcardField(field reflect.StructField) bool {
+	return field.Type.Kind() == reflect.Slice && field.Type.Elem() == anyElementType
+}
+
+// Returns the wildcard field of a group struct taking an element no other
+// field takes: the first one from the last field set, or the first one,
+// starting a new occurrence, if every wildcard comes before it.
+func itemWildcard(item reflect.Value) int {
+	last := 0
+	for i := 0; i < item.NumField(); i++ {
+		if !isEmptyGroupValue(item.Field(i), "omitempty") {
+			last = i
+		}
+	}
+
+	first := -1
+	for i := 0; i < item.NumField(); i++ {
+		if !isWildcardField(item.Type().Field(i)) {
+			continue
+		}
+		if i >= last {
+			return i
+		}
+		if first < 0 {
+			first = i
+		}
+	}
+	return first
+}
+
+func groupTag(field reflect.StructField) (xml.Name, string) {
+	tag := field.Tag.Get("xml")
+	options := ""
+	if i := strings.Index(tag, ","); i >= 0 {
+		tag, options = tag[:i], tag[i:]
+	}
+
+	if tag == "" {
+		return xml.Name{Local: field.Name}, options
+	}
+	if i := strings.Index(tag, " "); i >= 0 {
+		return xml.Name{Space: tag[:i], Local: tag[i+1:]}, options
+	}
+	return xml.Name{Local: tag}, options
+}
+
+// Tells whether an element decoded into field must start a new occurrence
+// instead of completing item: in a choice any other field is already taken,
+// in a sequence a later field is. Lists and nested groups take any number
+// of elements.
+func startsOccurrence(item reflect.Value, field int, choice bool) bool {
+	for i := 0; i < item.NumField(); i++ {
+		if isEmptyGroupValue(item.Field(i), "omitempty") || i < field && !choice {
+			continue
+		}
+
+		_, options := groupTag(item.Type().Field(i))
+		if i != field || item.Field(i).Kind() != reflect.Slice && !strings.Contains(options, "any") {
+			return true
+		}
+	}
+
+	return false
+}
+
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+type derivedType struct {
+	name   xml.Name
+	goType reflect.Type
+}
+
+// Types that may be given as xsi:type for a polymorphic type, by the
+// interface they implement. The base type comes first.
+var derivedTypes = map[reflect.Type][]derivedType{}
+
+// registerDerived records a type that may be given as xsi:type, given a nil
+// pointer to the interface of the base type and one to the type.
+func registerDerived(base interface{}, name xml.Name, value interface{}) {
+	t := reflect.TypeOf(base).Elem()
+	derivedTypes[t] = append(derivedTypes[t], derivedType{name: name, goType: reflect.TypeOf(value)})
+}
+
+// unmarshalDerived decodes an element into field, a pointer to the interface
+// of a polymorphic type, as the type named by its xsi:type or as the base
+// type if it has none.
+func unmarshalDerived(d *xml.Decoder, start xml.StartElement, field interface{}) error {
+	v := reflect.ValueOf(field).Elem()
+
+	types := derivedTypes[v.Type()]
+	if len(types) == 0 {
+		return d.Skip()
+	}
+
+	t := types[0].goType
+	if name, ok := xsiType(start); ok {
+		for _, derived := range types {
+			if derived.name.Local == name.Local && (name.Space == "" || derived.name.Space == name.Space) {
+				t = derived.goType
+				break
+			}
+		}
+	}
+
+	value := reflect.New(t.Elem())
+	applyDefaults(value.Interface())
+	start = xml.StartElement{Name: declaredName(t.Elem(), start.Name), Attr: withoutDeclarations(start.Attr)}
+	{{if .Mixed}}if _, ok := value.Interface().(xml.Unmarshaler); !ok && mixedContent(value) != nil {
+		if err := unmarshalMixed(d, start, value.Interface(), mixedContent(value)); err != nil {
+			return err
+		}
+	} else {{end}}if err := d.DecodeElement(value.Interface(), &start); err != nil {
 		return err
 	}
-	defer res.Body.Close()
 
-	rawbody, err := ioutil.ReadAll(res.Body)
-	if len(rawbody) == 0 {
-		log.Println("empty response")
+	v.Set(value)
+	return nil
+}
+
+// marshalDerived encodes the value of field, a pointer to the interface of a
+// polymorphic type, adding its xsi:type unless it is the base type.
+func marshalDerived(e *xml.Encoder, start xml.StartElement, field interface{}) error {
+	v := reflect.ValueOf(field).Elem()
+	if v.IsNil() {
 		return nil
 	}
 
-	log.Println(string(rawbody))
-	respEnvelope := new(SOAPEnvelope)
-	respEnvelope.Body = SOAPBody{Content: response}
-	err = xml.Unmarshal(rawbody, respEnvelope)
-	if err != nil {
+	types := derivedTypes[v.Type()]
+	for i, derived := range types {
+		if i == 0 || derived.goType != v.Elem().Type() {
+			continue
+		}
+
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:xsi"}, Value: xsiNamespace})
+		if derived.name.Space == "" {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xsi:type"}, Value: derived.name.Local})
+		} else {
+			start.Attr = append(start.Attr,
+				xml.Attr{Name: xml.Name{Local: "xmlns:tns"}, Value: derived.name.Space},
+				xml.Attr{Name: xml.Name{Local: "xsi:type"}, Value: "tns:" + derived.name.Local})
+		}
+		break
+	}
+
+	{{if .Mixed}}if _, ok := v.Elem().Interface().(xml.Marshaler); !ok && mixedContent(v.Elem()) != nil {
+		return marshalMixed(e, start, v.Interface(), *mixedContent(v.Elem()))
+	}
+	{{end}}return e.EncodeElement(v.Interface(), start)
+}
+
+// Elements of substitution groups, by the struct generated for the group.
+var substitutes = map[reflect.Type][]derivedType{}
+
+// registerSubstitute records an element of a substitution group, given a nil
+// pointer to the struct of the group and one to the type of the element.
+func registerSubstitute(group interface{}, name xml.Name, value interface{}) {
+	t := reflect.TypeOf(group).Elem()
+	substitutes[t] = append(substitutes[t], derivedType{name: name, goType: reflect.TypeOf(value)})
+}
+
+func substituteFor(group reflect.Type, name xml.Name) (derivedType, bool) {
+	for _, member := range substitutes[group] {
+		if member.name.Local == name.Local && (name.Space == "" || member.name.Space == "" || member.name.Space == name.Space) {
+			return member, true
+		}
+	}
+	return derivedType{}, false
+}
+
+// unmarshalSubstitution decodes an element of a substitution group into
+// value, a new value of the type of the element, and records its name in
+// name. group points to the struct of the group. Other elements are skipped.
+func unmarshalSubstitution(d *xml.Decoder, start xml.StartElement, group interface{}, name *xml.Name, value *interface{}) error {
+	member, ok := substituteFor(reflect.TypeOf(group).Elem(), start.Name)
+	if !ok {
+		return d.Skip()
+	}
+
+	v := reflect.New(member.goType.Elem())
+	applyDefaults(v.Interface())
+	if err := d.DecodeElement(v.Interface(), &xml.StartElement{Name: declaredName(member.goType.Elem(), start.Name), Attr: start.Attr}); err != nil {
 		return err
 	}
 
-	fault := respEnvelope.Body.Fault
-	if fault != nil {
-		return fault
+	*name, *value = start.Name, v.Interface()
+	return nil
+}
+
+// marshalSubstitution encodes value as the element name, or when name is
+// empty as the first element of the group holding values of its type.
+func marshalSubstitution(e *xml.Encoder, group interface{}, name xml.Name, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	if name.Local == "" {
+		for _, member := range substitutes[reflect.TypeOf(group).Elem()] {
+			if member.goType == reflect.TypeOf(value) {
+				name = member.name
+				break
+			}
+		}
+	}
+	if name.Local == "" {
+		return fmt.Errorf("%T: no element of the substitution group holds a %T", group, value)
+	}
+
+	return e.EncodeElement(value, xml.StartElement{Name: name})
+}
+
+// namedElement encodes value as the element name, whatever the element its
+// type was decoded from.
+type namedElement struct {
+	name  xml.Name
+	value interface{}
+}
+
+func (e namedElement) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	return enc.EncodeElement(e.value, xml.StartElement{Name: e.name})
+}
+
+func (e namedElement) Validate() error {
+	return validate(e.value)
+}
+
+// AnyElement is an element matched by a wildcard, kept as found in the
+// document: Attr holds its attributes and namespace declarations, InnerXML
+// its content, in which the namespace of elements is declared wherever it
+// changes so that the element may be encoded back anywhere.
+type AnyElement struct {
+	XMLName  xml.Name
+	Attr     []xml.Attr
+	InnerXML string
+}
+
+var anyElementType = reflect.TypeOf(AnyElement{})
+
+func (a *AnyElement) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	a.XMLName, a.Attr = start.Name, append([]xml.Attr(nil), start.Attr...)
+
+	var inner bytes.Buffer
+	e := xml.NewEncoder(&inner)
+
+	// Namespaces and prefixes of the elements open, a first, and names of
+	// the elements of the content encoded.
+	spaces := []string{start.Name.Space}
+	scopes := []map[string]string{declaredPrefixes(nil, start.Attr)}
+	var names []xml.Name
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := len(spaces) - 1
+			prefixes := declaredPrefixes(scopes[n], t.Attr)
+			encoded := anyStart(t, &spaces[n], prefixes)
+			spaces, scopes, names = append(spaces, t.Name.Space), append(scopes, prefixes), append(names, encoded.Name)
+			token = encoded
+		case xml.EndElement:
+			if len(names) == 0 {
+				if err := e.Flush(); err != nil {
+					return err
+				}
+				a.InnerXML = inner.String()
+				return nil
+			}
+
+			n := len(names) - 1
+			token = xml.EndElement{Name: names[n]}
+			spaces, scopes, names = spaces[:n+1], scopes[:n+1], names[:n]
+		}
+
+		if err := e.EncodeToken(token); err != nil {
+			return err
+		}
+	}
+}
+
+func (a AnyElement) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	start := anyStart(xml.StartElement{Name: a.XMLName, Attr: a.Attr}, nil, declaredPrefixes(nil, a.Attr))
+	return e.EncodeElement(struct {
+		InnerXML string ` + "`" + `xml:",innerxml"` + "`" + `
+	}{a.InnerXML}, start)
+}
+
+// Returns a start element found in a document as encoded back by
+// AnyElement. The namespace of the element is declared as the default
+// namespace unless it is the one of its parent, which is unknown when
+// parent is nil. Namespace declarations are kept, and attributes use the
+// prefix declared for their namespace, if any.
+func anyStart(start xml.StartElement, parent *string, prefixes map[string]string) xml.StartElement {
+	encoded := xml.StartElement{Name: start.Name}
+	switch {
+	case parent != nil && start.Name.Space == *parent:
+		encoded.Name.Space = ""
+	case start.Name.Space == "":
+		encoded.Attr = append(encoded.Attr, xml.Attr{Name: xml.Name{Local: "xmlns"}})
+	}
+
+	for _, attr := range start.Attr {
+		switch {
+		case attr.Name.Space == "xmlns":
+			attr.Name = xml.Name{Local: "xmlns:" + attr.Name.Local}
+		case isNamespaceDeclaration(attr.Name):
+			continue
+		case attr.Name.Space != "" && prefixes[attr.Name.Space] != "":
+			attr.Name = xml.Name{Local: prefixes[attr.Name.Space] + ":" + attr.Name.Local}
+		}
+		encoded.Attr = append(encoded.Attr, attr)
+	}
+	return encoded
+}
+
+// Returns the prefixes declared in the scope of an element, by namespace,
+// given those declared in the scope of its parent.
+func declaredPrefixes(parent map[string]string, attrs []xml.Attr) map[string]string {
+	prefixes := make(map[string]string, len(parent))
+	for space, prefix := range parent {
+		prefixes[space] = prefix
+	}
+
+	for _, attr := range attrs {
+		if attr.Name.Space != "xmlns" {
+			continue
+		}
+		for space, prefix := range prefixes {
+			if prefix == attr.Name.Local {
+				delete(prefixes, space)
+			}
+		}
+		prefixes[attr.Value] = attr.Name.Local
+	}
+	return prefixes
+}
+
+func isNamespaceDeclaration(name xml.Name) bool {
+	return name.Space == "xmlns" || name.Space == "" && name.Local == "xmlns"
+}
+
+// Tells whether an attribute is a namespace declaration or an attribute of
+// the xsi namespace, such as xsi:type, which wildcards don't match.
+func isInstanceAttribute(name xml.Name) bool {
+	return isNamespaceDeclaration(name) || name.Space == xsiNamespace
+}
+
+// withoutDeclarations returns attributes less namespace declarations and
+// xsi attributes, which encoding/xml hands to the fields taking any
+// attribute but which are encoded on their own.
+func withoutDeclarations(attrs []xml.Attr) []xml.Attr {
+	var kept []xml.Attr
+	for _, attr := range attrs {
+		if !isInstanceAttribute(attr.Name) {
+			kept = append(kept, attr)
+		}
+	}
+	return kept
+}
+
+// MixedContent is the content of an element of a mixed complex type: its
+// text and child elements in document order. The child elements are held by
+// the fields of the type, the content only names them.
+type MixedContent []MixedItem
+
+// MixedItem is a run of text, or a child element when Element is set.
+type MixedItem struct {
+	Text    string
+	Element xml.Name
+}
+
+// Text returns the text of the content, less its child elements.
+func (c MixedContent) Text() string {
+	var text strings.Builder
+	for _, item := range c {
+		if item.Element.Local == "" {
+			text.WriteString(item.Text)
+		}
+	}
+	return text.String()
+}
+
+// unmarshalMixed decodes start into v like unmarshalGroups, recording the
+// text of the element and the names of its children in content.
+func unmarshalMixed(d *xml.Decoder, start xml.StartElement, v interface{}, content *MixedContent, groups ...interface{}) error {
+	*content = nil
+	md := xml.NewTokenDecoder(&mixedReader{d: d, start: &start, content: content})
+
+	// The element must be open in md, which unmarshalGroups reads its
+	// content from.
+	if _, err := md.Token(); err != nil {
+		return err
+	}
+	return unmarshalGroups(md, start, v, groups...)
+}
+
+// mixedReader passes on the tokens of an element, recording its text and the
+// names of its children.
+type mixedReader struct {
+	d       *xml.Decoder
+	start   *xml.StartElement
+	depth   int
+	content *MixedContent
+}
+
+func (r *mixedReader) Token() (xml.Token, error) {
+	if r.start != nil {
+		start := *r.start
+		r.start = nil
+		return start, nil
+	}
+
+	token, err := r.d.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	content := *r.content
+	switch t := token.(type) {
+	case xml.StartElement:
+		if r.depth == 0 {
+			content = append(content, MixedItem{Element: t.Name})
+		}
+		r.depth++
+	case xml.EndElement:
+		r.depth--
+	case xml.CharData:
+		if r.depth != 0 {
+			break
+		}
+		if n := len(content); n > 0 && content[n-1].Element.Local == "" {
+			content[n-1].Text += string(t)
+		} else {
+			content = append(content, MixedItem{Text: string(t)})
+		}
+	}
+	*r.content = content
+
+	return token, nil
+}
+
+// mixedContent returns the content of v, a pointer to the struct of a mixed
+// type, or nil for other types. Extended mixed types have no methods keeping
+// their text, unmarshalDerived and marshalDerived do.
+func mixedContent(v reflect.Value) *MixedContent {
+	if field := v.Elem().FieldByName("Content"); field.IsValid() {
+		content, _ := field.Addr().Interface().(*MixedContent)
+		return content
+	}
+	return nil
+}
+
+// marshalMixed encodes v, which must not implement xml.Marshaler itself, as
+// start with the text of content. Its child elements, which encoding/xml
+// encodes in the order of the fields, are moved where content names them,
+// the ones it doesn't name coming last.
+func marshalMixed(e *xml.Encoder, start xml.StartElement, v interface{}, content MixedContent) error {
+	var elements bytes.Buffer
+	if err := xml.NewEncoder(&elements).EncodeElement(v, start); err != nil {
+		return err
+	}
+
+	d := xml.NewDecoder(&elements)
+	token, err := d.Token()
+	if err != nil {
+		return err
+	}
+	root := token.(xml.StartElement)
+
+	var children []AnyElement
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		if _, ok := token.(xml.EndElement); ok {
+			break
+		}
+		if t, ok := token.(xml.StartElement); ok {
+			var child AnyElement
+			if err := child.UnmarshalXML(d, t); err != nil {
+				return err
+			}
+			children = append(children, child)
+		}
+	}
+
+	var inner bytes.Buffer
+	ie := xml.NewEncoder(&inner)
+	encoded := make([]bool, len(children))
+	encode := func(child int) error {
+		encoded[child] = true
+		return children[child].MarshalXML(ie, xml.StartElement{})
+	}
+
+	for _, item := range content {
+		if item.Element.Local == "" {
+			if err := ie.EncodeToken(xml.CharData(item.Text)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Unqualified children of a qualified element are encoded in its
+		// namespace by encoding/xml.
+		for i, child := range children {
+			name := child.XMLName
+			if !encoded[i] && name.Local == item.Element.Local && (item.Element.Space == "" || item.Element.Space == name.Space) {
+				if err := encode(i); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	for i := range children {
+		if !encoded[i] {
+			if err := encode(i); err != nil {
+				return err
+			}
+		}
+	}
+	if err := ie.Flush(); err != nil {
+		return err
+	}
+
+	return AnyElement{XMLName: root.Name, Attr: root.Attr, InnerXML: inner.String()}.MarshalXML(e, start)
+}
+
+// Nillable is the value of a nillable element, which is encoded with
+// xsi:nil="true" and no content when Nil is set. It is only generated for
+// schemas declaring nillable elements, being generic needs Go 1.18.
+type Nillable[T any] struct {
+	Value T
+	Nil   bool
+}
+
+// NewNillable returns a Nillable holding v.
+func NewNillable[T any](v T) Nillable[T] {
+	return Nillable[T]{Value: v}
+}
+
+func (n Nillable[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if !n.Nil {
+		return e.EncodeElement(n.Value, start)
+	}
+
+	start.Attr = append(start.Attr,
+		xml.Attr{Name: xml.Name{Local: "xmlns:xsi"}, Value: xsiNamespace},
+		xml.Attr{Name: xml.Name{Local: "xsi:nil"}, Value: "true"})
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	return e.EncodeToken(start.End())
+}
+
+func (n *Nillable[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Space == xsiNamespace && attr.Name.Local == "nil" {
+			if isNil, _ := strconv.ParseBool(strings.TrimSpace(attr.Value)); isNil {
+				*n = Nillable[T]{Nil: true}
+				return d.Skip()
+			}
+		}
+	}
+
+	*n = Nillable[T]{}
+	start.Name = declaredName(reflect.TypeOf(&n.Value).Elem(), start.Name)
+	return d.DecodeElement(&n.Value, &start)
+}
+
+// Validate validates the value unless nil.
+func (n Nillable[T]) Validate() error {
+	if n.Nil {
+		return nil
+	}
+	return validate(n.Value)
+}
+
+// Sets a nillable element to the value of the lexical form text.
+func (n *Nillable[T]) setDefault(text string) error {
+	*n = Nillable[T]{}
+	return parseDefault(text, reflect.ValueOf(&n.Value).Elem())
+}
+
+// Returns the value held unless nil.
+func (n Nillable[T]) nillableValue() (interface{}, bool) {
+	return n.Value, !n.Nil
+}
+
+// applyDefaults sets the default and fixed values of the elements and
+// attributes of v, a pointer to a generated struct, if it has any.
+func applyDefaults(v interface{}) {
+	if d, ok := v.(interface{ setDefaults() }); ok {
+		d.setDefaults()
+	}
+}
+
+// setDefault sets field, a pointer to the field of an element or attribute,
+// to its default or fixed value. Values the field can't hold are ignored.
+func setDefault(field interface{}, value string) {
+	parseDefault(value, reflect.ValueOf(field).Elem())
+}
+
+// Decodes the lexical form of a default value into v, allocating pointers.
+func parseDefault(text string, v reflect.Value) error {
+	if d, ok := v.Addr().Interface().(interface{ setDefault(string) error }); ok {
+		return d.setDefault(text)
+	}
+	if v.Kind() == reflect.Ptr {
+		value := reflect.New(v.Type().Elem())
+		if err := parseDefault(text, value.Elem()); err != nil {
+			return err
+		}
+		v.Set(value)
+		return nil
+	}
+
+	value := reflect.New(v.Type()).Elem()
+	if err := parseSimpleValue(text, value); err != nil {
+		return err
+	}
+	v.Set(value)
+	return nil
+}
+
+// Returns the xsi:type of an element. Its prefix is resolved against the
+// namespaces declared on the element itself, the namespace is left empty
+// when declared further up.
+func xsiType(start xml.StartElement) (xml.Name, bool) {
+	for _, attr := range start.Attr {
+		if attr.Name.Space != xsiNamespace || attr.Name.Local != "type" {
+			continue
+		}
+
+		prefix, name := "", xml.Name{Local: strings.TrimSpace(attr.Value)}
+		if i := strings.Index(name.Local, ":"); i >= 0 {
+			prefix, name.Local = name.Local[:i], name.Local[i+1:]
+		}
+
+		for _, ns := range start.Attr {
+			if ns.Name.Space == "xmlns" && ns.Name.Local == prefix || prefix == "" && ns.Name.Space == "" && ns.Name.Local == "xmlns" {
+				name.Space = ns.Value
+			}
+		}
+		return name, true
+	}
+
+	return xml.Name{}, false
+}
+
+// Returns the element name a struct declares through a tagged XMLName
+// field, which encoding/xml requires the decoded element to have, or name
+// if it declares none.
+func declaredName(t reflect.Type, name xml.Name) xml.Name {
+	if t.Kind() != reflect.Struct {
+		return name
+	}
+
+	field, ok := t.FieldByName("XMLName")
+	if !ok || field.Type != reflect.TypeOf(xml.Name{}) {
+		return name
+	}
+
+	tag := strings.Split(field.Tag.Get("xml"), ",")[0]
+	if tag == "" {
+		return name
+	}
+	if i := strings.Index(tag, " "); i >= 0 {
+		return xml.Name{Space: tag[:i], Local: tag[i+1:]}
+	}
+	return xml.Name{Space: name.Space, Local: tag}
+}
+
+// unmarshalList decodes the whitespace separated items of an xs:list into
+// list, a pointer to a slice.
+func unmarshalList(text []byte, list interface{}) error {
+	v := reflect.ValueOf(list).Elem()
+	items := strings.Fields(string(text))
+
+	s := reflect.MakeSlice(v.Type(), len(items), len(items))
+	for i, item := range items {
+		if err := parseSimpleValue(item, s.Index(i)); err != nil {
+			return err
+		}
+	}
+
+	v.Set(s)
+	return nil
+}
+
+// marshalList encodes the items of an xs:list separated by spaces.
+func marshalList(list interface{}) ([]byte, error) {
+	v := reflect.ValueOf(list)
+
+	items := make([]string, v.Len())
+	for i := range items {
+		item, err := formatSimpleValue(v.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+
+	return []byte(strings.Join(items, " ")), nil
+}
+
+// unmarshalUnion keeps the lexical form of an xs:union value and decodes it
+// as the first of members, nil pointers to the member types, accepting it.
+// Members with a Validate method only accept values it finds valid.
+func unmarshalUnion(text []byte, lexical *string, value *interface{}, members ...interface{}) error {
+	*lexical, *value = string(text), nil
+
+	for _, member := range members {
+		v := reflect.New(reflect.TypeOf(member).Elem())
+		if parseSimpleValue(strings.TrimSpace(*lexical), v.Elem()) != nil {
+			continue
+		}
+		if validator, ok := v.Interface().(interface{ Validate() error }); ok && validator.Validate() != nil {
+			continue
+		}
+
+		*value = v.Elem().Interface()
+		break
+	}
+
+	return nil
+}
+
+// marshalUnion encodes the lexical form of an xs:union value, or its value
+// if the lexical form is empty.
+func marshalUnion(lexical string, value interface{}) ([]byte, error) {
+	if lexical != "" || value == nil {
+		return []byte(lexical), nil
+	}
+
+	text, err := formatSimpleValue(reflect.ValueOf(value))
+	return []byte(text), err
+}
+
+// Decodes the lexical form of a simple value into v.
+func parseSimpleValue(text string, v reflect.Value) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(text))
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		return parseSimpleValue(text, v.Elem())
+	case reflect.String:
+		v.SetString(text)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("cannot decode %q into %s", text, v.Type())
+	}
+
+	return nil
+}
+
+// Encodes a simple value in its lexical form.
+func formatSimpleValue(v reflect.Value) (string, error) {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+	// Types such as big.Int implement it on pointers.
+	if v.Kind() == reflect.Struct {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		if m, ok := p.Interface().(encoding.TextMarshaler); ok {
+			text, err := m.MarshalText()
+			return string(text), err
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "", nil
+		}
+		return formatSimpleValue(v.Elem())
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	}
+
+	return "", fmt.Errorf("cannot encode %s", v.Type())
+}
+
+// XSDDecimal is a xs:decimal kept as found in the document, so that no
+// digit is lost.
+type XSDDecimal string
+
+var decimalPattern = regexp.MustCompile(` + "`" + `^[+-]?(\d+(\.\d*)?|\.\d+)$` + "`" + `)
+
+// NewXSDDecimal returns r as a xs:decimal with the given number of digits
+// after the decimal point, rounded.
+func NewXSDDecimal(r *big.Rat, digits int) XSDDecimal {
+	return XSDDecimal(r.FloatString(digits))
+}
+
+// Rat returns the value of a decimal, or nil if it's empty.
+func (d XSDDecimal) Rat() *big.Rat {
+	r, ok := new(big.Rat).SetString(string(d))
+	if !ok {
+		return nil
+	}
+	return r
+}
+
+func (d XSDDecimal) MarshalText() ([]byte, error) {
+	return []byte(d), nil
+}
+
+func (d *XSDDecimal) UnmarshalText(text []byte) error {
+	lexical := strings.TrimSpace(string(text))
+	if !decimalPattern.MatchString(lexical) {
+		return fmt.Errorf("invalid xs:decimal %q", lexical)
+	}
+	*d = XSDDecimal(lexical)
+	return nil
+}
+
+// XSDStringList is a xs:NMTOKENS, xs:IDREFS or xs:ENTITIES value.
+type XSDStringList []string
+
+func (l XSDStringList) MarshalText() ([]byte, error) {
+	return marshalList(l)
+}
+
+func (l *XSDStringList) UnmarshalText(text []byte) error {
+	return unmarshalList(text, l)
+}
+
+// XSDBase64Binary is binary data encoded in base64.
+type XSDBase64Binary []byte
+
+func (b XSDBase64Binary) MarshalText() ([]byte, error) {
+	return []byte(base64.StdEncoding.EncodeToString(b)), nil
+}
+
+func (b *XSDBase64Binary) UnmarshalText(text []byte) error {
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(text)), ""))
+	if err != nil {
+		return err
+	}
+	*b = data
+	return nil
+}
+
+// XSDHexBinary is binary data encoded in hexadecimal.
+type XSDHexBinary []byte
+
+func (b XSDHexBinary) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(hex.EncodeToString(b))), nil
+}
+
+func (b *XSDHexBinary) UnmarshalText(text []byte) error {
+	data, err := hex.DecodeString(strings.TrimSpace(string(text)))
+	if err != nil {
+		return err
+	}
+	*b = data
+	return nil
+}
+
+// XSDDuration is a xs:duration, such as P1Y2M3DT4H5M6.7S. Years and months
+// have no fixed length, so the components are kept as they are.
+type XSDDuration struct {
+	Negative    bool
+	Years       int
+	Months      int
+	Days        int
+	Hours       int
+	Minutes     int
+	Seconds     int
+	Nanoseconds int
+}
+
+var durationPattern = regexp.MustCompile(` + "`" + `^(-)?P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)D)?(T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)(?:\.(\d+))?S)?)?$` + "`" + `)
+
+// NewXSDDuration returns the duration of d in days, hours, minutes and
+// seconds.
+func NewXSDDuration(d time.Duration) XSDDuration {
+	duration := XSDDuration{Negative: d < 0}
+	if d < 0 {
+		d = -d
+	}
+
+	duration.Days, d = int(d/(24*time.Hour)), d%(24*time.Hour)
+	duration.Hours, d = int(d/time.Hour), d%time.Hour
+	duration.Minutes, d = int(d/time.Minute), d%time.Minute
+	duration.Seconds, d = int(d/time.Second), d%time.Second
+	duration.Nanoseconds = int(d)
+	return duration
+}
+
+// Duration converts a duration without years and months to a time.Duration.
+func (d XSDDuration) Duration() (time.Duration, bool) {
+	if d.Years != 0 || d.Months != 0 {
+		return 0, false
+	}
+
+	duration := time.Duration(d.Days)*24*time.Hour + time.Duration(d.Hours)*time.Hour +
+		time.Duration(d.Minutes)*time.Minute + time.Duration(d.Seconds)*time.Second + time.Duration(d.Nanoseconds)
+	if d.Negative {
+		duration = -duration
+	}
+	return duration, true
+}
+
+func (d XSDDuration) MarshalText() ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	if d.Negative {
+		buffer.WriteString("-")
+	}
+	buffer.WriteString("P")
+
+	if d.Years != 0 {
+		fmt.Fprintf(buffer, "%dY", d.Years)
+	}
+	if d.Months != 0 {
+		fmt.Fprintf(buffer, "%dM", d.Months)
+	}
+	if d.Days != 0 {
+		fmt.Fprintf(buffer, "%dD", d.Days)
+	}
+
+	if d.Hours != 0 || d.Minutes != 0 || d.Seconds != 0 || d.Nanoseconds != 0 {
+		buffer.WriteString("T")
+		if d.Hours != 0 {
+			fmt.Fprintf(buffer, "%dH", d.Hours)
+		}
+		if d.Minutes != 0 {
+			fmt.Fprintf(buffer, "%dM", d.Minutes)
+		}
+		if d.Seconds != 0 || d.Nanoseconds != 0 {
+			fmt.Fprintf(buffer, "%d", d.Seconds)
+			if d.Nanoseconds != 0 {
+				buffer.WriteString(strings.TrimRight(fmt.Sprintf(".%09d", d.Nanoseconds), "0"))
+			}
+			buffer.WriteString("S")
+		}
+	} else if buffer.Len() <= 2 {
+		buffer.WriteString("T0S")
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (d *XSDDuration) UnmarshalText(text []byte) error {
+	lexical := strings.TrimSpace(string(text))
+	m := durationPattern.FindStringSubmatch(lexical)
+	if m == nil || strings.HasSuffix(lexical, "P") || strings.HasSuffix(lexical, "T") {
+		return fmt.Errorf("invalid xs:duration %q", lexical)
+	}
+
+	duration := XSDDuration{Negative: m[1] != ""}
+	for i, n := range []*int{&duration.Years, &duration.Months, &duration.Days, nil, &duration.Hours, &duration.Minutes, &duration.Seconds} {
+		if n == nil || m[i+2] == "" {
+			continue
+		}
+		value, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			return fmt.Errorf("invalid xs:duration %q: %v", lexical, err)
+		}
+		*n = value
+	}
+	if fraction := m[9]; fraction != "" {
+		fraction = (fraction + "000000000")[:9]
+		duration.Nanoseconds, _ = strconv.Atoi(fraction)
+	}
+
+	*d = duration
+	return nil
+}
+
+// XSDGYear is a xs:gYear, a year with an optional timezone.
+type XSDGYear struct {
+	Year int
+	// Nil when the value has no timezone.
+	Timezone *time.Location
+}
+
+func (y XSDGYear) MarshalText() ([]byte, error) {
+	return []byte(formatYear(y.Year) + formatTimezone(y.Timezone)), nil
+}
+
+func (y *XSDGYear) UnmarshalText(text []byte) error {
+	lexical, timezone, err := parseTimezone(string(text))
+	if err != nil {
+		return err
+	}
+	year, err := parseYear(lexical)
+	if err != nil {
+		return fmt.Errorf("invalid xs:gYear %q", text)
+	}
+
+	*y = XSDGYear{Year: year, Timezone: timezone}
+	return nil
+}
+
+// XSDGYearMonth is a xs:gYearMonth, a month of a year with an optional
+// timezone.
+type XSDGYearMonth struct {
+	Year  int
+	Month time.Month
+	// Nil when the value has no timezone.
+	Timezone *time.Location
+}
+
+func (y XSDGYearMonth) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%s-%02d%s", formatYear(y.Year), y.Month, formatTimezone(y.Timezone))), nil
+}
+
+func (y *XSDGYearMonth) UnmarshalText(text []byte) error {
+	lexical, timezone, err := parseTimezone(string(text))
+	if err != nil {
+		return err
+	}
+
+	i := strings.LastIndex(lexical, "-")
+	if i <= 0 {
+		return fmt.Errorf("invalid xs:gYearMonth %q", text)
+	}
+	year, err := parseYear(lexical[:i])
+	if err != nil {
+		return fmt.Errorf("invalid xs:gYearMonth %q", text)
+	}
+	month, ok := parseDateField(lexical[i+1:], 1, 12)
+	if !ok {
+		return fmt.Errorf("invalid xs:gYearMonth %q", text)
+	}
+
+	*y = XSDGYearMonth{Year: year, Month: time.Month(month), Timezone: timezone}
+	return nil
+}
+
+// XSDGMonth is a xs:gMonth, a month recurring every year, with an optional
+// timezone.
+type XSDGMonth struct {
+	Month time.Month
+	// Nil when the value has no timezone.
+	Timezone *time.Location
+}
+
+func (m XSDGMonth) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("--%02d%s", m.Month, formatTimezone(m.Timezone))), nil
+}
+
+func (m *XSDGMonth) UnmarshalText(text []byte) error {
+	lexical, timezone, err := parseTimezone(string(text))
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(lexical, "--") {
+		return fmt.Errorf("invalid xs:gMonth %q", text)
+	}
+	month, ok := parseDateField(lexical[2:], 1, 12)
+	if !ok {
+		return fmt.Errorf("invalid xs:gMonth %q", text)
+	}
+
+	*m = XSDGMonth{Month: time.Month(month), Timezone: timezone}
+	return nil
+}
+
+// XSDGMonthDay is a xs:gMonthDay, a day recurring every year, with an
+// optional timezone.
+type XSDGMonthDay struct {
+	Month time.Month
+	Day   int
+	// Nil when the value has no timezone.
+	Timezone *time.Location
+}
+
+func (m XSDGMonthDay) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("--%02d-%02d%s", m.Month, m.Day, formatTimezone(m.Timezone))), nil
+}
+
+func (m *XSDGMonthDay) UnmarshalText(text []byte) error {
+	lexical, timezone, err := parseTimezone(string(text))
+	if err != nil {
+		return err
+	}
+	if len(lexical) != 7 || !strings.HasPrefix(lexical, "--") || lexical[4] != '-' {
+		return fmt.Errorf("invalid xs:gMonthDay %q", text)
+	}
+	month, ok := parseDateField(lexical[2:4], 1, 12)
+	if !ok {
+		return fmt.Errorf("invalid xs:gMonthDay %q", text)
+	}
+	// February 29 recurs on leap years.
+	day, ok := parseDateField(lexical[5:], 1, time.Date(2000, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day())
+	if !ok {
+		return fmt.Errorf("invalid xs:gMonthDay %q", text)
+	}
+
+	*m = XSDGMonthDay{Month: time.Month(month), Day: day, Timezone: timezone}
+	return nil
+}
+
+// XSDGDay is a xs:gDay, a day recurring every month, with an optional
+// timezone.
+type XSDGDay struct {
+	Day int
+	// Nil when the value has no timezone.
+	Timezone *time.Location
+}
+
+func (d XSDGDay) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("---%02d%s", d.Day, formatTimezone(d.Timezone))), nil
+}
+
+func (d *XSDGDay) UnmarshalText(text []byte) error {
+	lexical, timezone, err := parseTimezone(string(text))
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(lexical, "---") {
+		return fmt.Errorf("invalid xs:gDay %q", text)
+	}
+	day, ok := parseDateField(lexical[3:], 1, 31)
+	if !ok {
+		return fmt.Errorf("invalid xs:gDay %q", text)
+	}
+
+	*d = XSDGDay{Day: day, Timezone: timezone}
+	return nil
+}
+
+// XSDDateTime is a xs:dateTime. Values without timezone are held in UTC,
+// and told apart by HasTimezone so they encode without one.
+type XSDDateTime struct {
+	Time        time.Time
+	HasTimezone bool
+}
+
+// NewXSDDateTime returns t as a xs:dateTime with its timezone.
+func NewXSDDateTime(t time.Time) XSDDateTime {
+	return XSDDateTime{Time: t, HasTimezone: true}
+}
+
+func (d XSDDateTime) MarshalText() ([]byte, error) {
+	text := formatYear(d.Time.Year()) + d.Time.Format("-01-02T15:04:05.999999999")
+	if d.HasTimezone {
+		text += formatOffset(d.Time)
+	}
+	return []byte(text), nil
+}
+
+func (d *XSDDateTime) UnmarshalText(text []byte) error {
+	lexical, timezone, err := parseTimezone(string(text))
+	if err != nil {
+		return err
+	}
+
+	i := strings.Index(lexical, "T")
+	if i < 0 {
+		return fmt.Errorf("invalid xs:dateTime %q", text)
+	}
+	year, month, day, ok := parseDate(lexical[:i])
+	if !ok {
+		return fmt.Errorf("invalid xs:dateTime %q", text)
+	}
+	hour, minute, second, nanosecond, ok := parseClock(lexical[i+1:])
+	if !ok {
+		return fmt.Errorf("invalid xs:dateTime %q", text)
+	}
+
+	*d = XSDDateTime{HasTimezone: timezone != nil}
+	if timezone == nil {
+		timezone = time.UTC
+	}
+	// 24:00:00 is midnight of the next day.
+	d.Time = time.Date(year, month, day, hour, minute, second, nanosecond, timezone)
+	return nil
+}
+
+// XSDDate is a xs:date, held as midnight of the day in its timezone, or in
+// UTC when it has none, which only HasTimezone tells apart.
+type XSDDate struct {
+	Time        time.Time
+	HasTimezone bool
+}
+
+// NewXSDDate returns the day of t as a xs:date with the timezone of t.
+func NewXSDDate(t time.Time) XSDDate {
+	return XSDDate{Time: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()), HasTimezone: true}
+}
+
+func (d XSDDate) MarshalText() ([]byte, error) {
+	text := formatYear(d.Time.Year()) + d.Time.Format("-01-02")
+	if d.HasTimezone {
+		text += formatOffset(d.Time)
+	}
+	return []byte(text), nil
+}
+
+func (d *XSDDate) UnmarshalText(text []byte) error {
+	lexical, timezone, err := parseTimezone(string(text))
+	if err != nil {
+		return err
+	}
+
+	year, month, day, ok := parseDate(lexical)
+	if !ok {
+		return fmt.Errorf("invalid xs:date %q", text)
+	}
+
+	*d = XSDDate{HasTimezone: timezone != nil}
+	if timezone == nil {
+		timezone = time.UTC
+	}
+	d.Time = time.Date(year, month, day, 0, 0, 0, 0, timezone)
+	return nil
+}
+
+// XSDTime is a xs:time, held on January 1st of year 1 in its timezone, or
+// in UTC when it has none, which only HasTimezone tells apart.
+type XSDTime struct {
+	Time        time.Time
+	HasTimezone bool
+}
+
+// NewXSDTime returns the time of day of t as a xs:time with the offset of
+// t from UTC.
+func NewXSDTime(t time.Time) XSDTime {
+	_, offset := t.Zone()
+	timezone := time.FixedZone("", offset)
+	return XSDTime{Time: time.Date(1, 1, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), timezone), HasTimezone: true}
+}
+
+func (d XSDTime) MarshalText() ([]byte, error) {
+	text := d.Time.Format("15:04:05.999999999")
+	if d.HasTimezone {
+		text += formatOffset(d.Time)
+	}
+	return []byte(text), nil
+}
+
+func (d *XSDTime) UnmarshalText(text []byte) error {
+	lexical, timezone, err := parseTimezone(string(text))
+	if err != nil {
+		return err
+	}
+
+	hour, minute, second, nanosecond, ok := parseClock(lexical)
+	if !ok {
+		return fmt.Errorf("invalid xs:time %q", text)
+	}
+
+	*d = XSDTime{HasTimezone: timezone != nil}
+	if timezone == nil {
+		timezone = time.UTC
+	}
+	d.Time = time.Date(1, 1, 1, hour%24, minute, second, nanosecond, timezone)
+	return nil
+}
+
+// Parses the year-month-day lexical form of a date.
+func parseDate(lexical string) (int, time.Month, int, bool) {
+	n := len(lexical)
+	if n < 10 || lexical[n-6] != '-' || lexical[n-3] != '-' {
+		return 0, 0, 0, false
+	}
+
+	year, err := parseYear(lexical[:n-6])
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	month, ok := parseDateField(lexical[n-5:n-3], 1, 12)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	day, ok := parseDateField(lexical[n-2:], 1, time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day())
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	return year, time.Month(month), day, true
+}
+
+// Parses the hh:mm:ss lexical form of a time of day, with optional
+// fractional seconds. 24:00:00 is allowed for the end of a day.
+func parseClock(lexical string) (hour, minute, second, nanosecond int, ok bool) {
+	if len(lexical) < 8 || lexical[2] != ':' || lexical[5] != ':' {
+		return 0, 0, 0, 0, false
+	}
+
+	hour, ok1 := parseDateField(lexical[:2], 0, 24)
+	minute, ok2 := parseDateField(lexical[3:5], 0, 59)
+	second, ok3 := parseDateField(lexical[6:8], 0, 59)
+	if !ok1 || !ok2 || !ok3 {
+		return 0, 0, 0, 0, false
+	}
+
+	if fraction := lexical[8:]; fraction != "" {
+		digits := fraction[1:]
+		if fraction[0] != '.' || digits == "" || strings.Trim(digits, "0123456789") != "" {
+			return 0, 0, 0, 0, false
+		}
+		nanosecond, _ = strconv.Atoi((digits + "000000000")[:9])
+	}
+
+	if hour == 24 && (minute != 0 || second != 0 || nanosecond != 0) {
+		return 0, 0, 0, 0, false
+	}
+	return hour, minute, second, nanosecond, true
+}
+
+// Splits the optional timezone, Z or an offset such as +01:00, off the
+// lexical form of a date or time.
+func parseTimezone(lexical string) (string, *time.Location, error) {
+	lexical = strings.TrimSpace(lexical)
+	if strings.HasSuffix(lexical, "Z") {
+		return lexical[:len(lexical)-1], time.UTC, nil
+	}
+
+	n := len(lexical)
+	if n < 6 || lexical[n-6] != '+' && lexical[n-6] != '-' || lexical[n-3] != ':' {
+		return lexical, nil, nil
+	}
+
+	hours, ok := parseDateField(lexical[n-5:n-3], 0, 14)
+	minutes, ok2 := parseDateField(lexical[n-2:], 0, 59)
+	if !ok || !ok2 || hours == 14 && minutes != 0 {
+		return "", nil, fmt.Errorf("invalid timezone in %q", lexical)
+	}
+
+	offset := (hours*60 + minutes) * 60
+	if lexical[n-6] == '-' {
+		offset = -offset
+	}
+	return lexical[:n-6], time.FixedZone("", offset), nil
+}
+
+// Formats a timezone as Z or an offset, or nothing for no timezone.
+func formatTimezone(timezone *time.Location) string {
+	if timezone == nil {
+		return ""
+	}
+
+	return formatOffset(time.Date(2000, 1, 1, 0, 0, 0, 0, timezone))
+}
+
+// Formats the offset of t from UTC as Z or as +hh:mm or -hh:mm.
+func formatOffset(t time.Time) string {
+	_, offset := t.Zone()
+	if offset == 0 {
+		return "Z"
+	}
+
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, offset/3600, offset/60%60)
+}
+
+// Parses a year of at least four digits, without leading zeros beyond
+// those, and negative before year 1.
+func parseYear(lexical string) (int, error) {
+	digits := strings.TrimPrefix(lexical, "-")
+	if len(digits) < 4 || len(digits) > 4 && digits[0] == '0' || strings.Trim(digits, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid year %q", lexical)
+	}
+
+	year, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0, err
+	}
+	if lexical != digits {
+		year = -year
+	}
+	return year, nil
+}
+
+func formatYear(year int) string {
+	if year < 0 {
+		return fmt.Sprintf("-%04d", -year)
+	}
+	return fmt.Sprintf("%04d", year)
+}
+
+// Parses a two digits field of a date or time between min and max.
+func parseDateField(lexical string, min, max int) (int, bool) {
+	if len(lexical) != 2 || strings.Trim(lexical, "0123456789") != "" {
+		return 0, false
+	}
+
+	n, _ := strconv.Atoi(lexical)
+	return n, n >= min && n <= max
+}
+
+// ValidationError reports a value breaking a constraint of the schema. Path
+// locates the value from the one validated, as in "Item[2]/@id", and is
+// empty for the validated value itself.
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors lists every problem found validating a value.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Records err, found validating the value at path. The paths of nested
+// validation errors are made relative to the parent value.
+func (e *ValidationErrors) add(path string, err error) {
+	switch err := err.(type) {
+	case nil:
+	case ValidationErrors:
+		for _, nested := range err {
+			e.add(path, nested)
+		}
+	case *ValidationError:
+		nested := err.Path
+		if path != "" && nested != "" {
+			nested = "/" + nested
+		}
+		*e = append(*e, &ValidationError{Path: path + nested, Message: err.Message})
+	default:
+		*e = append(*e, &ValidationError{Path: path, Message: err.Error()})
+	}
+}
+
+// Checks the number of occurrences of the element or attribute at path held
+// by v: the length of a slice, otherwise one unless v is missing.
+func (e *ValidationErrors) occurs(path string, v interface{}, min, max int) {
+	// Elements are missing when nil, as are required attributes of types
+	// held as pointers; those held as values are always encoded. Values of
+	// polymorphic types and substitution groups are missing when they hold
+	// none.
+	n := 0
+	switch value := reflect.ValueOf(v); {
+	case !value.IsValid():
+	case value.Kind() == reflect.Slice:
+		n = value.Len()
+	case value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface:
+		if !value.IsNil() {
+			n = 1
+		}
+	case value.Kind() == reflect.Struct && value.FieldByName("Value").Kind() == reflect.Interface:
+		if !value.FieldByName("Value").IsNil() {
+			n = 1
+		}
+	default:
+		n = 1
+	}
+
+	switch {
+	case n == 0 && min > 0 && strings.HasPrefix(path, "@"):
+		e.add(path, fmt.Errorf("required attribute is missing"))
+	case n == 0 && min > 0:
+		e.add(path, fmt.Errorf("required element is missing"))
+	case n < min:
+		e.add(path, fmt.Errorf("%d occurrences, at least %d required", n, min))
+	case max >= 0 && n > max:
+		e.add(path, fmt.Errorf("%d occurrences, at most %d allowed", n, max))
+	}
+}
+
+// Checks that the elements or attributes matched by a wildcard, held by v,
+// are in the namespaces it allows: one of namespaces, or none of them when
+// other is set, "" standing for no namespace. Elements are located by their
+// index in path, attributes by their name appended to path.
+func (e *ValidationErrors) wildcard(path string, v interface{}, other bool, namespaces ...string) {
+	allowed := func(space string) bool {
+		return containsString(namespaces, space) != other
+	}
+
+	switch v := v.(type) {
+	case []AnyElement:
+		for i, el := range v {
+			if !allowed(el.XMLName.Space) {
+				e.add(fmt.Sprintf("%s[%d]", path, i+1), fmt.Errorf("namespace %q is not allowed", el.XMLName.Space))
+			}
+		}
+	case []xml.Attr:
+		for _, attr := range v {
+			if !isInstanceAttribute(attr.Name) && !allowed(attr.Name.Space) {
+				e.add(path+attr.Name.Local, fmt.Errorf("namespace %q is not allowed", attr.Name.Space))
+			}
+		}
+	}
+}
+
+// Checks that the element or attribute at path held by v has its fixed
+// value, unless missing. Values are compared once decoded, so that their
+// lexical forms may differ.
+func (e *ValidationErrors) fixed(path string, v interface{}, fixed string) {
+	e.checkFixed(path, reflect.ValueOf(v), fixed)
+}
+
+func (e *ValidationErrors) checkFixed(path string, v reflect.Value, fixed string) {
+	if !v.IsValid() {
+		return
+	}
+
+	if n, ok := v.Interface().(interface{ nillableValue() (interface{}, bool) }); ok {
+		if value, ok := n.nillableValue(); ok {
+			e.checkFixed(path, reflect.ValueOf(value), fixed)
+		}
+		return
+	}
+
+	switch {
+	case v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface:
+		if !v.IsNil() {
+			e.checkFixed(path, v.Elem(), fixed)
+		}
+		return
+	case v.Kind() == reflect.Slice && !isTextValue(v):
+		for i := 0; i < v.Len(); i++ {
+			e.checkFixed(fmt.Sprintf("%s[%d]", path, i+1), v.Index(i), fixed)
+		}
+		return
+	case strings.HasPrefix(path, "@") && v.IsZero():
+		return
+	}
+
+	if !equalsLexical(v, fixed) {
+		text, _ := formatSimpleValue(v)
+		e.add(path, fmt.Errorf("%q is not the fixed value %q", text, fixed))
+	}
+}
+
+// Tells whether a value of a slice type is a simple value encoded as text,
+// such as a list or binary data, rather than repeated occurrences.
+func isTextValue(v reflect.Value) bool {
+	_, ok := v.Interface().(encoding.TextMarshaler)
+	return ok
+}
+
+// Tells whether a simple value equals the value of the lexical form text.
+// Numbers are compared by value, other values by their canonical lexical
+// form.
+func equalsLexical(v reflect.Value, text string) bool {
+	if v.Kind() != reflect.String {
+		if c, ok, _ := compareSimpleValue(v, strings.TrimSpace(text)); ok {
+			return c == 0
+		}
+	}
+
+	expected := reflect.New(v.Type()).Elem()
+	if parseSimpleValue(text, expected) != nil {
+		return false
+	}
+
+	actual, err := formatSimpleValue(v)
+	canonical, err2 := formatSimpleValue(expected)
+	return err == nil && err2 == nil && actual == canonical
+}
+
+// Validates v, the value at path, with its Validate method. Elements of
+// slices without one are validated one by one.
+func (e *ValidationErrors) check(path string, v interface{}) {
+	e.checkValue(path, reflect.ValueOf(v))
+}
+
+func (e *ValidationErrors) checkValue(path string, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+	}
+
+	if validator, ok := asValidator(v); ok {
+		e.add(path, validator.Validate())
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		e.checkValue(path, v.Elem())
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			e.checkValue(fmt.Sprintf("%s[%d]", path, i+1), v.Index(i))
+		}
+	}
+}
+
+type validator interface {
+	Validate() error
+}
+
+// Returns v as a validator, reaching the methods of its pointer even when v
+// isn't addressable.
+func asValidator(v reflect.Value) (validator, bool) {
+	if v.CanAddr() {
+		if val, ok := v.Addr().Interface().(validator); ok {
+			return val, true
+		}
+	}
+
+	if val, ok := v.Interface().(validator); ok {
+		return val, true
+	}
+
+	if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		val, ok := p.Interface().(validator)
+		return val, ok
+	}
+	return nil, false
+}
+
+// validate validates v with its Validate method, if it has one.
+func validate(v interface{}) error {
+	var errs ValidationErrors
+	errs.check("", v)
+	return errs.err()
+}
+
+// simpleFacets are the constraints of a restricted simple type. Bounds,
+// digits and lengths are in their lexical form, empty when not constrained.
+type simpleFacets struct {
+	enumeration    []string
+	patterns       []*regexp.Regexp
+	whiteSpace     string
+	minInclusive   string
+	maxInclusive   string
+	minExclusive   string
+	maxExclusive   string
+	totalDigits    string
+	fractionDigits string
+	length         string
+	minLength      string
+	maxLength      string
+}
+
+// validateFacets checks a simple value against the facets of its type. The
+// lexical form of the value is normalized as its whiteSpace facet says
+// before being checked.
+func validateFacets(v interface{}, f *simpleFacets) error {
+	value := reflect.ValueOf(v)
+	text, err := formatSimpleValue(value)
+	if err != nil {
+		return err
+	}
+	text = normalizeWhiteSpace(text, f.whiteSpace)
+
+	var errs ValidationErrors
+	if len(f.enumeration) > 0 && !containsString(f.enumeration, text) {
+		errs.add("", fmt.Errorf("%q is not one of %q", text, f.enumeration))
+	}
+
+	if len(f.patterns) > 0 && !matchesPattern(f.patterns, text) {
+		patterns := make([]string, len(f.patterns))
+		for i, pattern := range f.patterns {
+			patterns[i] = pattern.String()
+		}
+		errs.add("", fmt.Errorf("%q does not match pattern %s", text, strings.Join(patterns, " | ")))
+	}
+
+	// Bounds that aren't values of the type are reported rather than
+	// skipped, as the value can't be known to be within them.
+	compare := func(bound string) (int, bool) {
+		c, ok, err := compareSimpleValue(value, bound)
+		if err != nil {
+			errs.add("", fmt.Errorf("invalid bound %q: %v", bound, err))
+		}
+		return c, ok
+	}
+	if c, ok := compare(f.minInclusive); ok && c < 0 {
+		errs.add("", fmt.Errorf("%s is less than %s", text, f.minInclusive))
+	}
+	if c, ok := compare(f.maxInclusive); ok && c > 0 {
+		errs.add("", fmt.Errorf("%s is greater than %s", text, f.maxInclusive))
+	}
+	if c, ok := compare(f.minExclusive); ok && c <= 0 {
+		errs.add("", fmt.Errorf("%s is not greater than %s", text, f.minExclusive))
+	}
+	if c, ok := compare(f.maxExclusive); ok && c >= 0 {
+		errs.add("", fmt.Errorf("%s is not less than %s", text, f.maxExclusive))
+	}
+
+	if total, fraction, ok := simpleValueDigits(value, text); ok {
+		if max, err := strconv.Atoi(f.totalDigits); err == nil && total > max {
+			errs.add("", fmt.Errorf("%s has %d digits, at most %d allowed", text, total, max))
+		}
+		if max, err := strconv.Atoi(f.fractionDigits); err == nil && fraction > max {
+			errs.add("", fmt.Errorf("%s has %d fraction digits, at most %d allowed", text, fraction, max))
+		}
+	}
+
+	if n, ok := simpleValueLength(value, text); ok {
+		if length, err := strconv.Atoi(f.length); err == nil && n != length {
+			errs.add("", fmt.Errorf("length is %d, must be %d", n, length))
+		}
+		if min, err := strconv.Atoi(f.minLength); err == nil && n < min {
+			errs.add("", fmt.Errorf("length is %d, must be at least %d", n, min))
+		}
+		if max, err := strconv.Atoi(f.maxLength); err == nil && n > max {
+			errs.add("", fmt.Errorf("length is %d, must be at most %d", n, max))
+		}
+	}
+
+	return errs.err()
+}
+
+// Normalizes the lexical form of a value as a whiteSpace facet says.
+func normalizeWhiteSpace(text, whiteSpace string) string {
+	switch whiteSpace {
+	case "replace":
+		return strings.Map(func(r rune) rune {
+			if r == '\t' || r == '\n' || r == '\r' {
+				return ' '
+			}
+			return r
+		}, text)
+	case "collapse":
+		return strings.Join(strings.Fields(text), " ")
+	}
+	return text
+}
+
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesPattern(patterns []*regexp.Regexp, s string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compares a numeric value with a bound in its lexical form. Values of other
+// types, and empty bounds, can't be compared. Decimals held as strings and
+// big integers are compared exactly. An error is returned for bounds that
+// aren't numbers of the type of the value.
+func compareSimpleValue(v reflect.Value, bound string) (int, bool, error) {
+	if bound == "" {
+		return 0, false, nil
+	}
+
+	c := 0
+	switch v.Kind() {
+	case reflect.String, reflect.Struct:
+		if v.Kind() == reflect.Struct && !v.Type().ConvertibleTo(bigIntType) {
+			return 0, false, nil
+		}
+		text, err := formatSimpleValue(v)
+		if err != nil {
+			return 0, false, nil
+		}
+		value, ok := new(big.Rat).SetString(text)
+		if !ok {
+			return 0, false, nil
+		}
+		b, ok := new(big.Rat).SetString(bound)
+		if !ok {
+			return 0, false, fmt.Errorf("not a decimal")
+		}
+		c = value.Cmp(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b, err := strconv.ParseInt(bound, 10, 64)
+		if err != nil {
+			return 0, false, err
+		}
+		if v.Int() < b {
+			c = -1
+		} else if v.Int() > b {
+			c = 1
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b, err := strconv.ParseUint(bound, 10, 64)
+		if err != nil {
+			return 0, false, err
+		}
+		if v.Uint() < b {
+			c = -1
+		} else if v.Uint() > b {
+			c = 1
+		}
+	case reflect.Float32, reflect.Float64:
+		b, err := strconv.ParseFloat(bound, 64)
+		if err != nil {
+			return 0, false, err
+		}
+		if v.Float() < b {
+			c = -1
+		} else if v.Float() > b {
+			c = 1
+		}
+	default:
+		return 0, false, nil
+	}
+	return c, true, nil
+}
+
+var bigIntType = reflect.TypeOf(big.Int{})
+
+// Counts the significant digits of a decimal value, and those of its
+// fraction. Values that aren't decimal numbers have none.
+func simpleValueDigits(v reflect.Value, text string) (total, fraction int, ok bool) {
+	if v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64 {
+		text = strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+	}
+
+	digits := strings.TrimLeft(strings.TrimSpace(text), "+-")
+	integer, decimals := digits, ""
+	if i := strings.Index(digits, "."); i >= 0 {
+		integer, decimals = digits[:i], digits[i+1:]
+	}
+	if integer+decimals == "" || strings.Trim(integer+decimals, "0123456789") != "" {
+		return 0, 0, false
+	}
+
+	integer = strings.TrimLeft(integer, "0")
+	decimals = strings.TrimRight(decimals, "0")
+	total = len(integer) + len(decimals)
+	if total == 0 {
+		total = 1
+	}
+	return total, len(decimals), true
+}
+
+// Returns the length of a simple value: the number of characters of the
+// normalized text of a string, of octets of binary data or of items of a
+// list.
+func simpleValueLength(v reflect.Value, text string) (int, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return len([]rune(text)), true
+	case reflect.Slice:
+		return v.Len(), true
+	}
+	return 0, false
+}
+
+// validateList validates the items of an xs:list.
+func validateList(list interface{}) error {
+	v := reflect.ValueOf(list)
+
+	var errs ValidationErrors
+	for i := 0; i < v.Len(); i++ {
+		if err := validate(v.Index(i).Interface()); err != nil {
+			errs.add("", fmt.Errorf("item %d: %v", i+1, err))
+		}
+	}
+	return errs.err()
+}
+
+// validateUnion checks that a union value was accepted by a member type.
+func validateUnion(lexical string, value interface{}) error {
+	if value == nil {
+		if lexical == "" {
+			return nil
+		}
+		return fmt.Errorf("%q is not valid for any member type", lexical)
+	}
+	return validate(value)
+}
+
+func NewSOAPClient(url string, tls bool, auth *BasicAuth) *SOAPClient {
+	return &SOAPClient{
+		url: url,
+		tls: tls,
+		auth: auth,
+	}
+}
+
+// NewSOAP12Client returns a client that sends SOAP 1.2 envelopes.
+func NewSOAP12Client(url string, tls bool, auth *BasicAuth) *SOAPClient {
+	client := NewSOAPClient(url, tls, auth)
+	client.soap12 = true
+
+	return client
+}
+
+// SetValidation makes Call validate requests with their Validate method
+// before sending them, and return the validation errors instead.
+func (s *SOAPClient) SetValidation(validate bool) {
+	s.validate = validate
+}
+
+func (s *SOAPClient) Call(soapAction string, request, response interface{}) error {
+	if s.validate {
+		if err := validate(request); err != nil {
+			return err
+		}
+	}
+
+	namespace := soap11Namespace
+	if s.soap12 {
+		namespace = soap12Namespace
+	}
+
+	envelope := SOAPEnvelope{
+		XMLName: xml.Name{Space: namespace, Local: "Envelope"},
+	//Header:        SoapHeader{},
+	}
+
+	envelope.Body.XMLName = xml.Name{Space: namespace, Local: "Body"}
+	envelope.Body.Content = request
+	buffer := new(bytes.Buffer)
+
+	encoder := xml.NewEncoder(buffer)
+	//encoder.Indent("  ", "    ")
+
+	err := encoder.Encode(envelope)
+	if err == nil {
+		err = encoder.Flush()
+	}
+
+	log.Println(buffer.String())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", s.url, buffer)
+	if s.auth != nil {
+		req.SetBasicAuth(s.auth.Login, s.auth.Password)
+	}
+
+	if s.soap12 {
+		contentType := "application/soap+xml; charset=utf-8"
+		if soapAction != "" {
+			contentType += "; action=\"" + soapAction + "\""
+		}
+		req.Header.Add("Content-Type", contentType)
+	} else {
+		req.Header.Add("Content-Type", "text/xml; charset=\"utf-8\"")
+		if soapAction != "" {
+			req.Header.Add("SOAPAction", soapAction)
+		}
+	}
+
+	req.Header.Set("User-Agent", "gowsdl/0.1")
+	req.Close = true
+
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: s.tls,
+		},
+		Dial: dialTimeout,
+	}
+
+	client := &http.Client{Transport: tr}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	rawbody, err := ioutil.ReadAll(res.Body)
+	if len(rawbody) == 0 {
+		log.Println("empty response")
+		return nil
+	}
+
+	log.Println(string(rawbody))
+	respEnvelope := new(SOAPEnvelope)
+	respEnvelope.Body = SOAPBody{Content: response}
+	err = xml.Unmarshal(rawbody, respEnvelope)
+	if err != nil {
+		return err
+	}
+
+	fault := respEnvelope.Body.Fault
+	if fault != nil {
+		return fault
+	}
+
+	fault12 := respEnvelope.Body.Fault12
+	if fault12 != nil {
+		return fault12
 	}
 
 	return nil
@@ -0,0 +1,88 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+// substitutionGroup is a global element other elements declare themselves
+// substitutable for. Fields referencing the head hold any of its members,
+// picked by element name.
+type substitutionGroup struct {
+	// Struct holding the element name and value of a member.
+	Name string
+	// Slice of Name holding the members of a repeated reference.
+	ListName string
+	Head     *XSDElement
+	// The head, unless abstract, and every element substitutable for it.
+	Members []*XSDElement
+}
+
+// indexSubstitutions resolves the head of every element declaring a
+// substitutionGroup, across all schemas.
+func (g *GoWSDL) indexSubstitutions() {
+	g.substitutionGroups = nil
+	g.substitutions = make(map[*XSDElement]*substitutionGroup)
+
+	members := make(map[*XSDElement][]*XSDElement)
+	var heads []*XSDElement
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, element := range schema.Elements {
+			if element.SubstitutionGroup == "" {
+				continue
+			}
+
+			head := g.lookupElement(element.SubstitutionGroup)
+			if head == nil || head == element {
+				continue
+			}
+
+			if members[head] == nil {
+				heads = append(heads, head)
+			}
+			members[head] = append(members[head], element)
+		}
+	}
+
+	for _, head := range heads {
+		sg := &substitutionGroup{Head: head}
+		g.declareGoName(sg, g.namespaces[head], head.Name+"Substitution")
+		sg.Name = g.goNames[sg]
+		g.declareGoName(&sg.ListName, "", sg.Name+"List")
+		sg.ListName = g.goNames[&sg.ListName]
+
+		// Members of members are substitutable for the head too.
+		seen := map[*XSDElement]bool{}
+		queue := []*XSDElement{head}
+		for len(queue) > 0 {
+			element := queue[0]
+			queue = queue[1:]
+			if seen[element] {
+				continue
+			}
+			seen[element] = true
+
+			if !element.Abstract {
+				sg.Members = append(sg.Members, element)
+			}
+			queue = append(queue, members[element]...)
+		}
+
+		g.substitutionGroups = append(g.substitutionGroups, sg)
+		g.substitutions[head] = sg
+	}
+}
+
+// substitutionOf returns the substitution group headed by a global element,
+// or nil if no element is substitutable for it.
+func (g *GoWSDL) substitutionOf(el *XSDElement) *substitutionGroup {
+	if el == nil {
+		return nil
+	}
+	return g.substitutions[el]
+}
+
+// listSubstitutionGroups returns the substitution groups to generate.
+func (g *GoWSDL) listSubstitutionGroups() []*substitutionGroup {
+	return g.substitutionGroups
+}
@@ -6,15 +6,104 @@ package gowsdl
 
 var typesTmpl = `
 {{define "SimpleType"}}
-	{{$type := replaceReservedWords .Name | makePublic}}
-	type {{$type}} {{toGoType .Restriction.Base}}
-	const (
-		{{with .Restriction}}
-			{{range .Enumeration}}
-				{{if .Doc}} {{.Doc | comment}} {{end}}
-				{{$type}}{{$value := replaceReservedWords .Value}}{{$value | makePublic}} {{$type}} = "{{$value}}" {{end}}
+	{{$type := goTypeName .}}
+	{{if .List}}
+		// {{$type}} is a whitespace separated list of {{listItemType .List}} values.
+		type {{$type}} []{{listItemType .List}}
+
+		func (l {{$type}}) MarshalText() ([]byte, error) {
+			return marshalList(l)
+		}
+
+		func (l *{{$type}}) UnmarshalText(text []byte) error {
+			return unmarshalList(text, l)
+		}
+
+		func (l {{$type}}) Validate() error {
+			return validateList(l)
+		}
+	{{else if .Union}}
+		{{$members := unionMemberTypes .Union}}
+		// {{$type}} is a union of {{range $i, $m := $members}}{{if $i}}, {{end}}{{$m}}{{end}}.
+		// Lexical holds the value as found in the document, Value the value
+		// decoded as the first member type accepting it, or nil if none does.
+		// Lexical is encoded as it is unless empty.
+		type {{$type}} struct {
+			Lexical string
+			Value   interface{}
+		}
+
+		func (u {{$type}}) MarshalText() ([]byte, error) {
+			return marshalUnion(u.Lexical, u.Value)
+		}
+
+		func (u *{{$type}}) UnmarshalText(text []byte) error {
+			return unmarshalUnion(text, &u.Lexical, &u.Value{{range $members}}, (*{{.}})(nil){{end}})
+		}
+
+		func (u {{$type}}) Validate() error {
+			return validateUnion(u.Lexical, u.Value)
+		}
+	{{else}}
+		type {{$type}} {{toGoType .Restriction.Base | valueType}}
+		{{$simpleType := .}}
+		const (
+			{{with .Restriction}}
+				{{range .Enumeration}}{{$enum := .}}{{with enumValue $simpleType .Value}}
+					{{if $enum.Doc}} {{$enum.Doc | comment}} {{end}}
+					{{$type}}{{replaceReservedWords $enum.Value | makePublic}} {{$type}} = {{.}} {{end}}{{end}}
+			{{end}}
+		)
+
+		{{with textBase .}}
+			{{if eq (pointer .) .}}
+				func (v *{{$type}}) MarshalText() ([]byte, error) {
+					return ({{.}})(v).MarshalText()
+				}
+
+				func (v *{{$type}}) UnmarshalText(text []byte) error {
+					return ({{.}})(v).UnmarshalText(text)
+				}
+			{{else}}
+				func (v {{$type}}) MarshalText() ([]byte, error) {
+					return {{.}}(v).MarshalText()
+				}
+
+				func (v *{{$type}}) UnmarshalText(text []byte) error {
+					return (*{{.}})(v).UnmarshalText(text)
+				}
+			{{end}}
+		{{end}}
+
+		{{$facets := facets .}}
+		{{if not $facets.Empty}}
+			var facets{{$type}} = &simpleFacets{ {{with $facets.Enumeration}}
+				enumeration: []string{{"{"}}{{range $i, $e := .}}{{if $i}}, {{end}}{{printf "%q" $e}}{{end}}},{{end}}{{with $facets.Patterns}}
+				patterns: []*regexp.Regexp{{"{"}}{{range $i, $e := .}}{{if $i}}, {{end}}regexp.MustCompile({{printf "%q" $e}}){{end}}},{{end}}{{with $facets.WhiteSpace}}
+				whiteSpace: {{printf "%q" .}},{{end}}{{with $facets.MinInclusive}}
+				minInclusive: {{printf "%q" .}},{{end}}{{with $facets.MaxInclusive}}
+				maxInclusive: {{printf "%q" .}},{{end}}{{with $facets.MinExclusive}}
+				minExclusive: {{printf "%q" .}},{{end}}{{with $facets.MaxExclusive}}
+				maxExclusive: {{printf "%q" .}},{{end}}{{with $facets.TotalDigits}}
+				totalDigits: {{printf "%q" .}},{{end}}{{with $facets.FractionDigits}}
+				fractionDigits: {{printf "%q" .}},{{end}}{{with $facets.Length}}
+				length: {{printf "%q" .}},{{end}}{{with $facets.MinLength}}
+				minLength: {{printf "%q" .}},{{end}}{{with $facets.MaxLength}}
+				maxLength: {{printf "%q" .}},{{end}}
+			}
 		{{end}}
-	)
+
+		{{with $facets.Assertions}}
+			// Validate doesn't check the assertions of {{$type}}:{{range .}}
+			//	{{.}}{{end}}
+		{{end}}func (v {{$type}}) Validate() error { {{with simpleBase .}}
+			if err := {{.}}(v).Validate(); err != nil {
+				return err
+			}
+			{{end}}
+			return {{if $facets.Empty}}nil{{else}}validateFacets(v, facets{{$type}}){{end}}
+		}
+	{{end}}
 {{end}}
 
 {{define "ComplexContent"}}
@@ -23,94 +112,417 @@ var typesTmpl = `
 		{{$baseType}}
 	{{end}}
 
-	{{template "Elements" .Extension.Sequence}}
+	{{with .Extension.Content}}
+		{{template "Particle" .}}
+	{{end}}
 	{{template "Attributes" .Extension.Attributes}}
+	{{template "AttributeGroups" .Extension.AttributeGroups}}
 {{end}}
 
 {{define "Attributes"}}
+	{{range attributeFields .}}
+		{{if .Doc}} {{.Doc | comment}} {{end}}
+		{{.Field}} {{.GoType}} ` + "`" + `xml:"{{with .Namespace}}{{.}} {{end}}{{.Name}},attr{{if not .Required}},omitempty{{end}}"` + "`" + `
+	{{end}}
+{{end}}
+
+{{define "AttributeGroups"}}
 	{{range .}}
-		{{if .Doc}} {{.Doc | comment}} {{end}} {{if not .Type}}
-			{{ .Name | makePublic}} {{toGoType .SimpleType.Restriction.Base}} ` + "`" + `xml:"{{.Name}},attr,omitempty"` + "`" + `
-		{{else}}
-			{{ .Name | makePublic}} {{toGoType .Type}} ` + "`" + `xml:"{{.Name}},attr,omitempty"` + "`" + `
+		{{with refAttributeGroup .}}
+			{{template "Attributes" .Attributes}}
+			{{template "AttributeGroups" .AttributeGroups}}
 		{{end}}
 	{{end}}
 {{end}}
 
+{{define "Particle"}}{{with groupOf .}} {{.Field}} {{.FieldType}} ` + "`" + `xml:",any"` + "`" + `
+	{{else}}{{if eq .Kind "element"}}{{template "Element" .Element}}{{else if eq .Kind "group"}}{{with refGroup .Group}}{{with .Content}}{{template "Particle" .}}{{end}}{{end}}{{else if eq .Kind "any"}}{{template "Wildcard" .}}{{else}}{{range .Particles}}{{template "Particle" .}}{{end}}{{end}}{{end}}{{end}}
+
+{{define "Wildcard"}}
+	// Elements matching xs:any namespace="{{.Any.NamespaceConstraint}}" processContents="{{.Any.Processing}}".
+	{{wildcardField .}} []AnyElement ` + "`" + `xml:",any"` + "`" + `
+{{end}}
+
+{{define "DecoderFields"}}{{range .}}, {{if .Wildcard -}}
+	&anyField{elements: &t.{{.Field}}, max: {{.Max}}
+	{{- with .After}}, after: []string{ {{- range $i, $name := .}}{{if $i}}, {{end}}{{printf "%q" $name}}{{end -}} }{{end}}
+	{{- if .Other}}, other: true{{end}}
+	{{- with .Namespaces}}, namespaces: []string{ {{- range $i, $namespace := .}}{{if $i}}, {{end}}{{printf "%q" $namespace}}{{end -}} }{{end -}}
+	}{{else}}&t.{{.Field}}{{end}}{{end}}{{end}}
+
+{{define "AttributeWildcard"}}{{with .}}
+	// Attributes matching xs:anyAttribute namespace="{{.NamespaceConstraint}}" processContents="{{.Processing}}".
+	AnyAttr []xml.Attr ` + "`" + `xml:",any,attr"` + "`" + `
+{{end}}{{end}}
+
+{{define "MixedContent"}}
+	// Content holds the text of the element and the order of its child elements.
+	Content MixedContent ` + "`" + `xml:"-"` + "`" + `
+{{end}}
+
 {{define "SimpleContent"}}
-	Value {{toGoType .Extension.Base}}{{template "Attributes" .Extension.Attributes}}
+	Value {{toGoType .Extension.Base}} ` + "`" + `xml:",chardata"` + "`" + `
+	{{template "Attributes" .Extension.Attributes}}
+	{{template "AttributeGroups" .Extension.AttributeGroups}}
+{{end}}
+
+{{define "Checks"}}{{if .}}var errs ValidationErrors
+	{{range .}}{{if .Occurs}} errs.occurs({{printf "%q" (or .Choice .Path)}}, t.{{.Field}}, {{.Min}}, {{.Max}})
+	{{end}}{{if .Nested}} errs.check({{printf "%q" .Path}}, t.{{.Field}})
+	{{end}}{{if .Fixed}} errs.fixed({{printf "%q" .Path}}, t.{{.Field}}, {{printf "%q" .Fixed}})
+	{{end}}{{if .Wildcard}} errs.wildcard({{printf "%q" .Path}}, t.{{.Field}}, {{.Other}}{{range .Namespaces}}, {{printf "%q" .}}{{end}})
+	{{end}}{{end}} return errs.err(){{else}}return nil{{end}}{{end}}
+
+{{define "Defaults"}}{{range .}}{{if .Base}}if t.{{.Field}} == nil {
+		t.{{.Field}} = new({{.Base}})
+	}
+	t.{{.Field}}.setDefaults()
+	{{else}}setDefault(&t.{{.Field}}, {{printf "%q" .Value}})
+	{{end}}{{end}}{{end}}
+
+{{define "ComplexTypeBody"}}
+	{{if ne .ComplexContent.Extension.Base ""}}
+		{{template "ComplexContent" .ComplexContent}}
+	{{else if ne .SimpleContent.Extension.Base ""}}
+		{{template "SimpleContent" .SimpleContent}}
+	{{else}}
+		{{with .Content}}
+			{{template "Particle" .}}
+		{{end}}
+		{{template "Attributes" .Attributes}}
+		{{template "AttributeGroups" .AttributeGroups}}
+	{{end}}
+	{{template "AttributeWildcard" attributeWildcard .}}
 {{end}}
 
 {{define "ComplexTypeInline"}}
-	{{replaceReservedWords .Name | makePublic}} struct {
+	{{replaceReservedWords .Name | makePublic}} {{if repeated .MaxOccurs}}[]{{else if optional .}}*{{end}}struct {
 	{{with .ComplexType}}
-		{{if ne .ComplexContent.Extension.Base ""}}
-			{{template "ComplexContent" .ComplexContent}}
-		{{else if ne .SimpleContent.Extension.Base ""}}
-			{{template "SimpleContent" .SimpleContent}}
+		{{template "ComplexTypeBody" restated .}}
+	{{end}}
+	} ` + "`" + `xml:"{{with elementNamespace .}}{{.}} {{end}}{{.Name}}{{if optional .}},omitempty{{end}}"` + "`" + `
+{{end}}
+
+{{define "ElementRef"}}
+	{{$el := refElement .Ref}}
+	{{if .Doc}} {{.Doc | comment}} {{"\n"}} {{end}}
+	{{if $el}}
+		{{$ns := elementNamespace $el}}
+		{{with substitutionOf $el}}
+			{{replaceReservedWords $el.Name | makePublic}} {{if repeated $.MaxOccurs}}{{.ListName}}{{else}}{{.Name}}{{end}} ` + "`" + `xml:",any"` + "`" + `
 		{{else}}
-			{{template "Elements" .Sequence}}
-			{{template "Elements" .Choice}}
-			{{template "Elements" .SequenceChoice}}
-			{{template "Elements" .All}}
-			{{template "Attributes" .Attributes}}
+			{{replaceReservedWords $el.Name | makePublic}} {{elementGoType $el | elementFieldType $}} ` + "`" + `xml:"{{if $ns}}{{$ns}} {{end}}{{$el.Name}}{{if optional $}},omitempty{{end}}"` + "`" + `
 		{{end}}
+	{{else}}
+		{{stripns .Ref | replaceReservedWords | makePublic}} {{if repeated .MaxOccurs}}[]{{end}}string ` + "`" + `xml:"{{stripns .Ref}},omitempty"` + "`" + `
 	{{end}}
-	} ` + "`" + `xml:"{{.Name}},omitempty"` + "`" + `
 {{end}}
 
-{{define "Elements"}}
-	{{range .}} {{if not .Type}} {{template "ComplexTypeInline" .}} {{else}} {{if .Doc}} {{.Doc | comment}} {{"\n"}} {{end}} {{replaceReservedWords .Name | makePublic}} {{if eq .MaxOccurs "unbounded"}}[]{{end}}{{.Type | toGoType}} ` + "`" + `xml:"{{.Name}},omitempty"` + "`" + ` {{end}}
+{{define "ChoiceElement"}}
+	{{if .Ref}}
+		{{$el := refElement .Ref}}
+		{{if $el}}
+			{{$ns := elementNamespace $el}}
+			{{with substitutionOf $el}}
+				{{replaceReservedWords $el.Name | makePublic}} {{if repeated $.MaxOccurs}}{{.ListName}}{{else}}*{{.Name}}{{end}} ` + "`" + `xml:",any"` + "`" + `
+			{{else}}
+				{{replaceReservedWords $el.Name | makePublic}} {{if repeated $.MaxOccurs}}[]{{elementGoType $el | fieldType}}{{else}}{{elementGoType $el | fieldType | pointer}}{{end}} ` + "`" + `xml:"{{if $ns}}{{$ns}} {{end}}{{$el.Name}},omitempty"` + "`" + `
+			{{end}}
+		{{else}}
+			{{stripns .Ref | replaceReservedWords | makePublic}} {{if repeated .MaxOccurs}}[]string{{else}}*string{{end}} ` + "`" + `xml:"{{stripns .Ref}},omitempty"` + "`" + `
+		{{end}}
+	{{else}}
+		{{if .Doc}} {{.Doc | comment}} {{"\n"}} {{end}}
+		{{replaceReservedWords .Name | makePublic}} {{if repeated .MaxOccurs}}[]{{.Type | toGoType | fieldType}}{{else}}{{.Type | toGoType | fieldType | pointer}}{{end}} ` + "`" + `xml:"{{with elementNamespace .}}{{.}} {{end}}{{.Name}},omitempty"` + "`" + `
 	{{end}}
 {{end}}
 
+{{define "Element"}}{{if .Ref}} {{template "ElementRef" .}} {{else if not .Type}} {{template "ComplexTypeInline" .}} {{else}} {{if .Doc}} {{.Doc | comment}} {{"\n"}} {{end}} {{replaceReservedWords .Name | makePublic}} {{toGoType .Type | elementFieldType .}} ` + "`" + `xml:"{{with elementNamespace .}}{{.}} {{end}}{{.Name}}{{if optional .}},omitempty{{end}}"` + "`" + ` {{end}}
+{{end}}
+
 {{range .Schemas}}
 	{{ $targetNamespace := .TargetNamespace }}
 
-	{{range .SimpleType}}
+	{{range .SimpleType}}{{if not (mapped .)}}
 		{{template "SimpleType" .}}
-	{{end}}
+	{{end}}{{end}}
 
 	{{range .Elements}}
 		{{if not .Type}}
 			{{/* ComplexTypeLocal */}}
 			{{$name := .Name}}
+			{{$typeName := goTypeName .}}
 			{{with .ComplexType}}
-				type {{$name | replaceReservedWords | makePublic}} struct {
+				type {{$typeName}} struct {
 					XMLName xml.Name ` + "`xml:\"{{$targetNamespace}} {{$name}}\"`" + `
-					{{if ne .ComplexContent.Extension.Base ""}}
-						{{template "ComplexContent" .ComplexContent}}
-					{{else if ne .SimpleContent.Extension.Base ""}}
-						{{template "SimpleContent" .SimpleContent}}
-					{{else}}
-						{{template "Elements" .Sequence}}
-						{{template "Elements" .Choice}}
-						{{template "Elements" .SequenceChoice}}
-						{{template "Elements" .All}}
-						{{template "Attributes" .Attributes}}
-					{{end}}
+					{{template "ComplexTypeBody" restated .}}
+					{{if mixed .}}{{template "MixedContent"}}{{end}}
+				}
+
+				{{$groups := groupDecoderFields .}}
+				{{$defaults := defaults .}}
+				{{$attributes := catchesAttributes .}}
+				{{$mixed := mixed .}}
+				{{if $defaults}}
+					// New{{$typeName}} returns a {{$typeName}} holding the default and fixed values of its elements and attributes.
+					func New{{$typeName}}() *{{$typeName}} {
+						t := &{{$typeName}}{}
+						t.setDefaults()
+						return t
+					}
+
+					func (t *{{$typeName}}) setDefaults() {
+						{{template "Defaults" $defaults -}}
+					}
+				{{end}}
+				{{if or $groups $defaults $attributes $mixed}}
+					func (t *{{$typeName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+						type alias {{$typeName}}
+						{{if $defaults}}t.setDefaults()
+						{{end}}{{if $attributes}}start.Attr = withoutDeclarations(start.Attr)
+						{{end}}return {{if $mixed}}unmarshalMixed(d, start, (*alias)(t), &t.Content{{template "DecoderFields" $groups}}){{else if $groups}}unmarshalGroups(d, start, (*alias)(t){{template "DecoderFields" $groups}}){{else}}d.DecodeElement((*alias)(t), &start){{end}}
+					}
+				{{end}}
+				{{if $mixed}}
+					func (t {{$typeName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+						type alias {{$typeName}}
+						start.Name = xml.Name{Space: {{printf "%q" $targetNamespace}}, Local: {{printf "%q" $name}}}
+						return marshalMixed(e, start, (*alias)(&t), t.Content)
+					}
+
+					// Text returns the text of the element, less its child elements.
+					func (t *{{$typeName}}) Text() string {
+						return t.Content.Text()
+					}
+				{{end}}
+
+				func (t *{{$typeName}}) Validate() error {
+					{{template "Checks" validations .}}
 				}
 			{{end}}
 		{{end}}
 	{{end}}
 
-	{{range .ComplexTypes}}
+	{{range .ComplexTypes}}{{if not (mapped .)}}
 		{{/* ComplexTypeGlobal */}}
-		{{$name := replaceReservedWords .Name | makePublic}}
+		{{$name := goTypeName .}}
+		{{/* Elements of any name may have the type, whose name is recorded. */}}
 		type {{$name}} struct {
-			XMLName xml.Name ` + "`xml:\"{{$targetNamespace}} {{.Name}}\"`" + `
-			{{if ne .ComplexContent.Extension.Base ""}}
-				{{template "ComplexContent" .ComplexContent}}
-			{{else if ne .SimpleContent.Extension.Base ""}}
-				{{template "SimpleContent" .SimpleContent}}
-			{{else}}
-				{{template "Elements" .Sequence}}
-				{{template "Elements" .Choice}}
-				{{template "Elements" .SequenceChoice}}
-				{{template "Elements" .All}}
-				{{template "Attributes" .Attributes}}
+			XMLName xml.Name
+			{{template "ComplexTypeBody" restated .}}
+			{{if mixed .}}{{template "MixedContent"}}{{end}}
+		}
+
+		{{$groups := groupDecoderFields .}}
+		{{$defaults := defaults .}}
+		{{if $defaults}}
+			// New{{$name}} returns a {{$name}} holding the default and fixed values of its elements and attributes.
+			func New{{$name}}() *{{$name}} {
+				t := &{{$name}}{}
+				t.setDefaults()
+				return t
+			}
+
+			func (t *{{$name}}) setDefaults() {
+				{{template "Defaults" $defaults -}}
+			}
+		{{end}}
+		{{/* The UnmarshalXML method of extended types would be promoted to the
+		types embedding them, their fields are decoded by unmarshalDerived
+		which sets their defaults, leaves out namespace declarations and keeps
+		the text of mixed types. */}}
+		{{$decodesDefaults := and $defaults (not (extended .))}}
+		{{$decodesAttributes := and (catchesAttributes .) (not (extended .))}}
+		{{$decodesMixed := and (mixed .) (not (extended .))}}
+		{{if or $groups $decodesDefaults $decodesAttributes $decodesMixed}}
+			func (t *{{$name}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+				type alias {{$name}}
+				{{if $decodesDefaults}}t.setDefaults()
+				{{end}}{{if $decodesAttributes}}start.Attr = withoutDeclarations(start.Attr)
+				{{end}}return {{if mixed .}}unmarshalMixed(d, start, (*alias)(t), &t.Content{{template "DecoderFields" $groups}}){{else if $groups}}unmarshalGroups(d, start, (*alias)(t){{template "DecoderFields" $groups}}){{else}}d.DecodeElement((*alias)(t), &start){{end}}
+			}
+		{{end}}
+		{{if $decodesMixed}}
+			func (t {{$name}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+				type alias {{$name}}
+				return marshalMixed(e, start, (*alias)(&t), t.Content)
+			}
+		{{end}}
+		{{if mixed .}}
+			// Text returns the text of the element, less its child elements.
+			func (t *{{$name}}) Text() string {
+				return t.Content.Text()
+			}
+		{{end}}
+
+		func (t *{{$name}}) Validate() error {
+			{{template "Checks" validations .}}
+		}
+	{{end}}{{end}}
+{{end}}
+
+{{range polymorphicTypes}}
+	// {{.Interface}} is implemented by {{.Name}} and the types derived from it.
+	type {{.Interface}} interface {
+		Get{{.Name}}() *{{.Name}}
+	}
+
+	func (t *{{.Name}}) Get{{.Name}}() *{{.Name}} {
+		return t
+	}
+
+	// {{.Value}} holds a {{.Name}} or any type derived from it, picked by
+	// xsi:type when decoding.
+	type {{.Value}} struct {
+		{{.Interface}}
+	}
+
+	func (v *{{.Value}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+		return unmarshalDerived(d, start, &v.{{.Interface}})
+	}
+
+	func (v {{.Value}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		return marshalDerived(e, start, &v.{{.Interface}})
+	}
+
+	func (v {{.Value}}) Validate() error {
+		return validate(v.{{.Interface}})
+	}
+
+	func init() {
+		{{$pt := .}}
+		{{range .Types}}
+			registerDerived((*{{$pt.Interface}})(nil), xml.Name{Space: "{{.Namespace}}", Local: "{{.Local}}"}, (*{{.GoName}})(nil))
+		{{end}}
+	}
+{{end}}
+
+{{range substitutionGroups}}
+	// {{.Name}} holds {{if .Head.Abstract}}an element substitutable for{{else}}a{{end}} {{.Head.Name}} element{{if not .Head.Abstract}} or any element of its substitution group{{end}}.
+	// Value points to the decoded element.
+	type {{.Name}} struct {
+		XMLName xml.Name
+		Value   interface{}
+	}
+
+	func (s *{{.Name}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+		return unmarshalSubstitution(d, start, s, &s.XMLName, &s.Value)
+	}
+
+	func (s {{.Name}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		return marshalSubstitution(e, &s, s.XMLName, s.Value)
+	}
+
+	func (s {{.Name}}) Validate() error {
+		return validate(s.Value)
+	}
+
+	type {{.ListName}} []{{.Name}}
+
+	func (s *{{.ListName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+		var item {{.Name}}
+		if err := item.UnmarshalXML(d, start); err != nil || item.Value == nil {
+			return err
+		}
+
+		*s = append(*s, item)
+		return nil
+	}
+
+	func (s {{.ListName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		for _, item := range s {
+			if err := item.MarshalXML(e, start); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	func init() {
+		{{$sg := .}}
+		{{range .Members}}
+			registerSubstitute((*{{$sg.Name}})(nil), xml.Name{Space: "{{elementNamespace .}}", Local: "{{.Name}}"}, ({{elementGoType . | fieldType | pointer}})(nil))
+		{{end}}
+	}
+{{end}}
+
+{{range groupTypes}}
+	{{$group := .}}
+	{{if eq .Kind "list"}}
+		type {{.Name}} struct {
+			{{range .Content.Particles}}
+				{{template "Particle" .}}
+			{{end}}
+		}
+
+		type {{.ListName}} []{{.Name}}
+
+		func (s *{{.ListName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+			return unmarshalGroup(d, start, s, {{.Choice}})
+		}
+
+		func (t *{{.Name}}) Validate() error {
+			{{template "Checks" groupValidations .}}
+		}
+
+		func (s {{.ListName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+			return marshalGroup(e, s)
+		}
+	{{else if eq .Kind "struct"}}
+		// {{.Name}} holds {{if .Optional}}at most{{else}}exactly{{end}} one of its alternatives.
+		type {{.Name}} struct {
+			{{range .Alternatives}}
+				{{if .Name}} {{.Field}} *{{.Name}} ` + "`" + `xml:",any"` + "`" + `
+				{{else}} {{template "ChoiceElement" .Particle.Element}}
+				{{end}}
 			{{end}}
 		}
+
+		func (c *{{.Name}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+			return unmarshalGroupItem(d, start, c)
+		}
+
+		func (c {{.Name}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+			return marshalGroupItem(e, &c)
+		}
+
+		// Validate reports an error unless {{if .Optional}}at most{{else}}exactly{{end}} one alternative is set.
+		func (c {{.Name}}) Validate() error {
+			return validateChoice(c, {{.Optional}})
+		}
+	{{else}}
+		// {{.Name}} is implemented by the alternatives of a choice{{range $i, $a := .Alternatives}}{{if $i}},{{else}}:{{end}} *{{.Name}}{{end}}.
+		type {{.Name}} interface {
+			is{{.Name}}()
+		}
+
+		func init() {
+			registerChoice((*{{.Name}})(nil){{range .Alternatives}}, (*{{.Name}})(nil){{end}})
+		}
+	{{end}}
+
+	{{range .Alternatives}}
+		{{if .Name}}
+			type {{.Name}} struct {
+				{{template "Particle" .Particle}}
+			}
+
+			{{if eq $group.Kind "interface"}}
+				func (*{{.Name}}) is{{$group.Name}}() {}
+			{{end}}
+
+			func (c *{{.Name}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+				return unmarshalGroupItem(d, start, c)
+			}
+
+			func (c {{.Name}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+				return marshalGroupItem(e, &c)
+			}
+
+			func (t *{{.Name}}) Validate() error {
+				{{template "Checks" alternativeValidations .}}
+			}
+		{{end}}
 	{{end}}
 {{end}}
 `
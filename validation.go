@@ -0,0 +1,423 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// fieldCheck is a check done by the Validate method generated for a struct
+// on one of its fields. Every field is validated itself, its number of
+// occurrences checked when Occurs is set, its value compared with Fixed
+// when set, and the namespaces of what a wildcard matched checked when
+// Wildcard is set.
+type fieldCheck struct {
+	Field string
+	// Location of the field in validation errors: the element name, the
+	// attribute name prefixed with @, or nothing for embedded types, simple
+	// content and choices.
+	Path string
+	// Elements of an interface choice, as an XPath union, locating the
+	// choice when missing.
+	Choice string
+	Occurs bool
+	Min    int
+	// Max is -1 when unbounded.
+	Max int
+	// Whether the type of the field may have a Validate method.
+	Nested bool
+	Fixed  string
+	// Namespaces allowed by a wildcard, or disallowed when Other is set.
+	Wildcard   bool
+	Other      bool
+	Namespaces []string
+}
+
+// Go types of XSD built-ins, which have no Validate method.
+var builtinGoTypes = map[string]bool{}
+
+func init() {
+	for _, goTypes := range []map[string]string{xsd2GoTypes, exactXSD2GoTypes} {
+		for _, goType := range goTypes {
+			builtinGoTypes[valueType(goType)] = true
+		}
+	}
+}
+
+// validatable tells whether values of a Go type may have a Validate method:
+// generated types do, anonymous structs and built-in types don't.
+func validatable(goType string) bool {
+	goType = strings.TrimPrefix(valueType(goType), "[]")
+	return goType != "" && !builtinGoTypes[goType]
+}
+
+// Appends a check unless there is nothing to check.
+func appendCheck(checks []*fieldCheck, check *fieldCheck) []*fieldCheck {
+	if !check.Occurs && !check.Nested && check.Fixed == "" && !check.Wildcard {
+		return checks
+	}
+	return append(checks, check)
+}
+
+// facetSet holds the facets of a restricted simple type.
+type facetSet struct {
+	Enumeration    []string
+	Patterns       []string
+	WhiteSpace     string
+	MinInclusive   string
+	MaxInclusive   string
+	MinExclusive   string
+	MaxExclusive   string
+	TotalDigits    string
+	FractionDigits string
+	Length         string
+	MinLength      string
+	MaxLength      string
+	// XPath 2.0 tests of XSD 1.1 assertions, which are not checked.
+	Assertions []string
+}
+
+// Empty tells whether a simple type has no facet to check.
+func (f *facetSet) Empty() bool {
+	return len(f.Enumeration) == 0 && len(f.Patterns) == 0 && f.WhiteSpace == "" &&
+		f.MinInclusive == "" && f.MaxInclusive == "" && f.MinExclusive == "" && f.MaxExclusive == "" &&
+		f.TotalDigits == "" && f.FractionDigits == "" && f.Length == "" && f.MinLength == "" && f.MaxLength == ""
+}
+
+// fieldName returns the name of the field generated for an element.
+func fieldName(name string) string {
+	return makePublic(replaceReservedWords(name))
+}
+
+// embeddedName returns the name of the field embedding a Go type, which
+// may be qualified by its package.
+func embeddedName(goType string) string {
+	goType = valueType(goType)
+	return goType[strings.LastIndex(goType, ".")+1:]
+}
+
+// occurrences parses a minOccurs or maxOccurs value, -1 meaning unbounded.
+func occurrences(value string) int {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 1
+	}
+	if value == "unbounded" {
+		return -1
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 1
+	}
+	return n
+}
+
+// validations returns the checks of the Validate method generated for a
+// complex type, following the fields generated by ComplexTypeBody.
+func (g *GoWSDL) validations(complexType *XSDComplexType) []*fieldCheck {
+	checks := g.contentChecks(complexType)
+	if wildcard := g.attributeWildcard(complexType); wildcard != nil {
+		checks = appendCheck(checks, g.wildcardCheck(&fieldCheck{Field: anyAttributesField, Path: "@"}, wildcard))
+	}
+	return checks
+}
+
+// Returns the checks of the fields generated for the content and the
+// attributes of a complex type.
+func (g *GoWSDL) contentChecks(complexType *XSDComplexType) []*fieldCheck {
+	complexType = g.restated(complexType)
+	var checks []*fieldCheck
+
+	if extension := complexType.ComplexContent.Extension; extension.Base != "" {
+		if g.lookupType(extension.Base) != nil {
+			checks = append(checks, &fieldCheck{Field: embeddedName(g.toGoType(extension.Base)), Nested: true})
+		}
+		checks = g.particleChecks(checks, extension.Content(), false)
+		return g.attributeChecks(checks, extension.Attributes, extension.AttributeGroups)
+	}
+
+	if extension := complexType.SimpleContent.Extension; extension.Base != "" {
+		checks = appendCheck(checks, &fieldCheck{Field: "Value", Nested: validatable(g.toGoType(extension.Base))})
+		return g.attributeChecks(checks, extension.Attributes, extension.AttributeGroups)
+	}
+
+	checks = g.particleChecks(checks, complexType.Content(), false)
+	return g.attributeChecks(checks, complexType.Attributes, complexType.AttributeGroups)
+}
+
+// groupValidations returns the checks of the Validate method generated for
+// an occurrence of a list.
+func (g *GoWSDL) groupValidations(gt *groupType) []*fieldCheck {
+	var checks []*fieldCheck
+	for _, p := range gt.Content.Particles {
+		checks = g.particleChecks(checks, p, gt.Choice)
+	}
+	return checks
+}
+
+// alternativeValidations returns the checks of the Validate method
+// generated for the struct of a choice alternative.
+func (g *GoWSDL) alternativeValidations(alternative *choiceAlternative) []*fieldCheck {
+	return g.particleChecks(nil, alternative.Particle, false)
+}
+
+// Appends the checks of the fields generated for a particle. Elements of
+// optional particles, and alternatives of choices, are not required.
+func (g *GoWSDL) particleChecks(checks []*fieldCheck, p *XSDParticle, optional bool) []*fieldCheck {
+	if p == nil {
+		return checks
+	}
+
+	optional = optional || p.MinOccurs == "0"
+
+	if gt := g.groupParticles[p]; gt != nil {
+		check := &fieldCheck{Field: gt.Field, Nested: true}
+		switch gt.Kind {
+		case groupList:
+			check.Path = gt.Field
+			check.Max = occurrences(p.MaxOccurs)
+			if !optional {
+				check.Min = occurrences(p.MinOccurs)
+			}
+			check.Occurs = check.Min > 0 || check.Max > 1
+		case groupChoiceInterface:
+			check.Choice = strings.Join(g.elementNames(nil, gt.Content), "|")
+			check.Occurs = !optional && !gt.Optional
+			check.Min, check.Max = 1, 1
+		}
+		return append(checks, check)
+	}
+
+	switch p.Kind {
+	case "element":
+		el := p.Element
+		decl, name, nested := el, el.Name, el.Type != "" && validatable(g.toGoType(el.Type))
+		if el.Ref != "" {
+			if decl = g.lookupElement(el.Ref); decl != nil {
+				name, nested = decl.Name, g.substitutionOf(decl) != nil || validatable(g.elementGoType(decl))
+			} else {
+				_, name = splitQName(el.Ref)
+			}
+		}
+
+		check := &fieldCheck{Field: fieldName(name), Path: name, Max: occurrences(p.MaxOccurs), Nested: nested}
+		if decl != nil && g.hasSimpleValue(decl) {
+			check.Fixed = decl.Fixed
+		}
+		if !optional {
+			check.Min = occurrences(p.MinOccurs)
+		}
+		check.Occurs = check.Min > 0 || check.Max > 1
+		return appendCheck(checks, check)
+	case "any":
+		field := g.wildcardFields[p]
+		check := &fieldCheck{Field: field, Path: field, Max: occurrences(p.MaxOccurs)}
+		if !optional {
+			check.Min = occurrences(p.MinOccurs)
+		}
+		check.Occurs = check.Min > 0 || check.Max >= 0
+		return appendCheck(checks, g.wildcardCheck(check, p.Any))
+	case "group":
+		if group := g.lookupGroup(p.Group); group != nil {
+			checks = g.particleChecks(checks, group.Content(), optional)
+		}
+	case "choice":
+		for _, child := range p.Particles {
+			checks = g.particleChecks(checks, child, optional || len(p.Particles) > 1)
+		}
+	case "sequence", "all":
+		for _, child := range p.Particles {
+			checks = g.particleChecks(checks, child, optional)
+		}
+	}
+
+	return checks
+}
+
+// Sets the namespaces a check allows the elements or attributes matched by
+// a wildcard to be in.
+func (g *GoWSDL) wildcardCheck(check *fieldCheck, wildcard *XSDWildcard) *fieldCheck {
+	check.Namespaces, check.Other = g.wildcardNamespaces(wildcard)
+	check.Wildcard = check.Namespaces != nil
+	return check
+}
+
+// Appends the checks of the fields generated for attributes.
+func (g *GoWSDL) attributeChecks(checks []*fieldCheck, attributes []*XSDAttribute, attributeGroups []*XSDAttributeGroup) []*fieldCheck {
+	for _, attribute := range g.attributeFields(attributes) {
+		check := &fieldCheck{Field: attribute.Field, Path: "@" + attribute.Name, Nested: validatable(attribute.GoType), Fixed: attribute.Fixed}
+		if attribute.Required {
+			check.Occurs, check.Min, check.Max = true, 1, 1
+		}
+		checks = appendCheck(checks, check)
+	}
+
+	for _, attributeGroup := range attributeGroups {
+		if resolved := g.lookupAttributeGroup(attributeGroup); resolved != nil {
+			checks = g.attributeChecks(checks, resolved.Attributes, resolved.AttributeGroups)
+		}
+	}
+
+	return checks
+}
+
+// facets returns the facets of a restricted simple type.
+func (g *GoWSDL) facets(simpleType *XSDSimpleType) *facetSet {
+	r := simpleType.Restriction
+	f := &facetSet{
+		Patterns:       goPatterns(simpleType.Name, r.Pattern),
+		WhiteSpace:     strings.TrimSpace(r.WhiteSpace.Value),
+		MinInclusive:   strings.TrimSpace(r.MinInclusive.Value),
+		MaxInclusive:   strings.TrimSpace(r.MaxInclusive.Value),
+		MinExclusive:   strings.TrimSpace(r.MinExclusive.Value),
+		MaxExclusive:   strings.TrimSpace(r.MaxExclusive.Value),
+		TotalDigits:    strings.TrimSpace(r.TotalDigits.Value),
+		FractionDigits: strings.TrimSpace(r.FractionDigits.Value),
+		Length:         strings.TrimSpace(r.Length.Value),
+		MinLength:      strings.TrimSpace(r.MinLength.Value),
+		MaxLength:      strings.TrimSpace(r.MaxLength.Value),
+	}
+
+	for _, value := range r.Enumeration {
+		f.Enumeration = append(f.Enumeration, value.Value)
+	}
+
+	for _, assertion := range r.Assertion {
+		if test := strings.Join(strings.Fields(assertion.Test), " "); test != "" {
+			f.Assertions = append(f.Assertions, test)
+		}
+	}
+
+	return f
+}
+
+// Returns the pattern facets of a simple type as anchored Go regular
+// expressions, a value having to match one of them. Nothing is returned if
+// one of them can't be translated or compiled, as checking the others would
+// reject values only it matches.
+func goPatterns(typeName string, patterns []XSDRestrictionValue) []string {
+	var exprs []string
+	for _, pattern := range patterns {
+		expr, err := goRegexp(pattern.Value)
+		if err == nil {
+			expr = "^(?:" + expr + ")$"
+			_, err = regexp.Compile(expr)
+		}
+		if err != nil {
+			log.Printf("[WARN] pattern of %s is not supported, it won't be validated: %v", typeName, err)
+			return nil
+		}
+		exprs = append(exprs, expr)
+	}
+	return exprs
+}
+
+// Escapes XSD gives another meaning than Go: its digits and word characters
+// are Unicode ones and its spaces leave out form feeds. The escapes of
+// complemented classes can't be written within Go character classes.
+var xsdEscapes = map[byte]struct{ outside, inside string }{
+	'd': {`\p{Nd}`, `\p{Nd}`},
+	'D': {`\P{Nd}`, `\P{Nd}`},
+	'w': {`[^\p{P}\p{Z}\p{C}]`, ""},
+	'W': {`[\p{P}\p{Z}\p{C}]`, `\p{P}\p{Z}\p{C}`},
+	's': {`[\t\n\r ]`, `\t\n\r `},
+	'S': {`[^\t\n\r ]`, ""},
+}
+
+// Translates an XSD regular expression into a Go one matching the same
+// strings. XSD has no anchors, ^ and $ are literal characters, and its dot
+// doesn't match carriage returns. Go has no character class subtraction,
+// such as [a-z-[aeiou]].
+func goRegexp(pattern string) (string, error) {
+	var expr strings.Builder
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			escape, ok := xsdEscapes[pattern[i]]
+			switch {
+			case !ok:
+				expr.WriteString(pattern[i-1 : i+1])
+			case !inClass:
+				expr.WriteString(escape.outside)
+			case escape.inside != "":
+				expr.WriteString(escape.inside)
+			default:
+				return "", fmt.Errorf("\\%c in a character class is not supported", pattern[i])
+			}
+		case inClass && c == '-' && strings.HasPrefix(pattern[i+1:], "["):
+			return "", errors.New("character class subtraction is not supported")
+		case inClass:
+			inClass = c != ']'
+			expr.WriteByte(c)
+		case c == '[':
+			inClass = true
+			expr.WriteByte(c)
+			// A leading ^ negates the class in both.
+			if strings.HasPrefix(pattern[i+1:], "^") {
+				expr.WriteByte('^')
+				i++
+			}
+		case c == '^' || c == '$':
+			expr.WriteByte('\\')
+			expr.WriteByte(c)
+		case c == '.':
+			expr.WriteString(`[^\n\r]`)
+		default:
+			expr.WriteByte(c)
+		}
+	}
+	return expr.String(), nil
+}
+
+// simpleBase returns the Go type of the generated simple type a simple type
+// restricts, if any.
+func (g *GoWSDL) simpleBase(simpleType *XSDSimpleType) string {
+	if _, ok := g.lookupType(simpleType.Restriction.Base).(*XSDSimpleType); !ok {
+		return ""
+	}
+	return valueType(g.toGoType(simpleType.Restriction.Base))
+}
+
+// textBase returns the Go type of the list, union or built-in declared by
+// the generated code a simple type restricts, directly or through other
+// restrictions, whose text encoding it shares. It is a pointer when the
+// text encoding is implemented on pointers.
+func (g *GoWSDL) textBase(simpleType *XSDSimpleType) string {
+	seen := map[*XSDSimpleType]bool{simpleType: true}
+	for base := simpleType; ; {
+		next, ok := g.lookupType(base.Restriction.Base).(*XSDSimpleType)
+		if !ok {
+			goType := g.toGoType(base.Restriction.Base)
+			if !isRuntimeType(goType) {
+				return ""
+			}
+			if base == simpleType {
+				return goType
+			}
+			// Types with methods on pointers are restricted as pointers.
+			if strings.HasPrefix(goType, "*") {
+				return "*" + g.simpleBase(simpleType)
+			}
+			return g.simpleBase(simpleType)
+		}
+		if seen[next] {
+			return ""
+		}
+		if next.List != nil || next.Union != nil {
+			return g.simpleBase(simpleType)
+		}
+		seen[next] = true
+		base = next
+	}
+}
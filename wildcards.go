@@ -0,0 +1,187 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Fields holding the elements and attributes matched by wildcards.
+const (
+	anyElementsField   = "Any"
+	anyAttributesField = "AnyAttr"
+)
+
+// indexWildcards records the target namespace of the schema declaring every
+// wildcard, which ##targetNamespace and ##other refer to, and names the
+// field holding the elements matched by every xs:any, in its place in the
+// struct: Any, Any2 and so on in the order of the struct, or after the
+// group declaring them, such as NoteAny, for the wildcards of named groups,
+// which are in every struct referencing the group.
+func (g *GoWSDL) indexWildcards() {
+	g.wildcardFields = make(map[*XSDParticle]string)
+
+	walkComplexType := func(complexType *XSDComplexType) {
+		complexType = g.restated(complexType)
+		g.nameWildcards(anyElementsField, complexType.Content(), complexType.ComplexContent.Extension.Content())
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		targetNamespace := schema.TargetNamespace
+		walkDeclarations(schema, nil, nil, func(wildcard *XSDWildcard) {
+			g.namespaces[wildcard] = targetNamespace
+		})
+
+		for _, group := range schema.Groups {
+			g.nameWildcards(makePublic(group.Name)+anyElementsField, group.Content())
+		}
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		walkDeclarations(schema, func(element *XSDElement) {
+			if element.Type == "" && element.Ref == "" && element.ComplexType != nil {
+				walkComplexType(element.ComplexType)
+			}
+		}, nil, nil)
+
+		for _, element := range schema.Elements {
+			if element.Type == "" && element.ComplexType != nil {
+				walkComplexType(element.ComplexType)
+			}
+		}
+		for _, complexType := range schema.ComplexTypes {
+			walkComplexType(complexType)
+		}
+	}
+
+	for _, gt := range g.groupTypes {
+		if gt.Kind == groupList {
+			g.nameWildcards(anyElementsField, gt.Content.Particles...)
+		}
+		for _, alternative := range gt.Alternatives {
+			if alternative.Name != "" {
+				g.nameWildcards(anyElementsField, alternative.Particle)
+			}
+		}
+	}
+}
+
+// Names the fields of the wildcards of a struct or group not named yet,
+// leaving out those of groups generated as structs of their own and of
+// named groups.
+func (g *GoWSDL) nameWildcards(prefix string, particles ...*XSDParticle) {
+	n := 0
+
+	var walk func(p *XSDParticle)
+	walk = func(p *XSDParticle) {
+		if p == nil || g.groupParticles[p] != nil {
+			return
+		}
+
+		switch p.Kind {
+		case "any":
+			if _, ok := g.wildcardFields[p]; ok {
+				return
+			}
+
+			n++
+			name := prefix
+			if n > 1 {
+				name += strconv.Itoa(n)
+			}
+			g.wildcardFields[p] = name
+		case "sequence", "choice", "all":
+			for _, child := range p.Particles {
+				walk(child)
+			}
+		}
+	}
+
+	for _, p := range particles {
+		walk(p)
+	}
+}
+
+// wildcardField returns the name of the field holding the elements matched
+// by an xs:any particle.
+func (g *GoWSDL) wildcardField(p *XSDParticle) string {
+	return g.wildcardFields[p]
+}
+
+// wildcardNamespaces returns the namespaces a wildcard allows, or those it
+// doesn't when other is set, "" standing for no namespace. Wildcards
+// allowing every namespace have none.
+func (g *GoWSDL) wildcardNamespaces(wildcard *XSDWildcard) (namespaces []string, other bool) {
+	targetNamespace := g.namespaces[wildcard]
+
+	switch constraint := wildcard.NamespaceConstraint(); constraint {
+	case "##any":
+		return nil, false
+	case "##other":
+		return []string{targetNamespace, ""}, true
+	default:
+		for _, namespace := range strings.Fields(constraint) {
+			switch namespace {
+			case "##targetNamespace":
+				namespace = targetNamespace
+			case "##local":
+				namespace = ""
+			}
+			namespaces = append(namespaces, namespace)
+		}
+		return namespaces, false
+	}
+}
+
+// attributeWildcard returns the attribute wildcard of the struct generated
+// for a complex type, declared by the type, the extension of its content or
+// one of their attribute groups.
+func (g *GoWSDL) attributeWildcard(complexType *XSDComplexType) *XSDWildcard {
+	complexType = g.restated(complexType)
+	if extension := complexType.ComplexContent.Extension; extension.Base != "" {
+		return g.findAttributeWildcard(extension.AnyAttribute, extension.AttributeGroups)
+	}
+	if extension := complexType.SimpleContent.Extension; extension.Base != "" {
+		return g.findAttributeWildcard(extension.AnyAttribute, extension.AttributeGroups)
+	}
+	return g.findAttributeWildcard(complexType.AnyAttribute, complexType.AttributeGroups)
+}
+
+func (g *GoWSDL) findAttributeWildcard(anyAttribute *XSDWildcard, attributeGroups []*XSDAttributeGroup) *XSDWildcard {
+	if anyAttribute != nil {
+		return anyAttribute
+	}
+
+	for _, attributeGroup := range attributeGroups {
+		if resolved := g.lookupAttributeGroup(attributeGroup); resolved != nil {
+			if wildcard := g.findAttributeWildcard(resolved.AnyAttribute, resolved.AttributeGroups); wildcard != nil {
+				return wildcard
+			}
+		}
+	}
+	return nil
+}
+
+// catchesAttributes tells whether the struct generated for a complex type,
+// or one of the base types it embeds, has a field taking any attribute.
+// encoding/xml passes namespace declarations to such fields as attributes,
+// which the UnmarshalXML method of the type leaves out.
+func (g *GoWSDL) catchesAttributes(complexType *XSDComplexType) bool {
+	seen := map[*XSDComplexType]bool{}
+	for complexType != nil && !seen[complexType] {
+		if g.attributeWildcard(complexType) != nil {
+			return true
+		}
+		seen[complexType] = true
+
+		base, _ := g.lookupType(complexType.ComplexContent.Extension.Base).(*XSDComplexType)
+		if base != nil && g.isMapped(base) {
+			return false
+		}
+		complexType = base
+	}
+	return false
+}
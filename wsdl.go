@@ -41,42 +41,50 @@ type WSDLMessage struct {
 	Name  string      `xml:"name,attr"`
 	Doc   string      `xml:"documentation"`
 	Parts []*WSDLPart `xml:"http://schemas.xmlsoap.org/wsdl/ part"`
+
+	namespace string
 }
 
 // WSDLFault represents a WSDL fault message.
 type WSDLFault struct {
-	Name      string        `xml:"name,attr"`
-	Message   string        `xml:"message,attr"`
-	Doc       string        `xml:"documentation"`
-	SOAPFault WSDLSOAPFault `xml:"http://schemas.xmlsoap.org/wsdl/soap/ fault"`
+	Name        string        `xml:"name,attr"`
+	Message     string        `xml:"message,attr"`
+	Doc         string        `xml:"documentation"`
+	SOAPFault   WSDLSOAPFault `xml:"http://schemas.xmlsoap.org/wsdl/soap/ fault"`
+	SOAP12Fault WSDLSOAPFault `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ fault"`
 }
 
 // WSDLInput represents a WSDL input message.
 type WSDLInput struct {
-	Name       string            `xml:"name,attr"`
-	Message    string            `xml:"message,attr"`
-	Doc        string            `xml:"documentation"`
-	SOAPBody   WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap/ body"`
-	SOAPHeader []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap/ header"`
+	Name         string            `xml:"name,attr"`
+	Message      string            `xml:"message,attr"`
+	Doc          string            `xml:"documentation"`
+	SOAPBody     WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap/ body"`
+	SOAPHeader   []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap/ header"`
+	SOAP12Body   WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ body"`
+	SOAP12Header []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ header"`
 }
 
 // WSDLOutput represents a WSDL output message.
 type WSDLOutput struct {
-	Name       string            `xml:"name,attr"`
-	Message    string            `xml:"message,attr"`
-	Doc        string            `xml:"documentation"`
-	SOAPBody   WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap/ body"`
-	SOAPHeader []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap/ header"`
+	Name         string            `xml:"name,attr"`
+	Message      string            `xml:"message,attr"`
+	Doc          string            `xml:"documentation"`
+	SOAPBody     WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap/ body"`
+	SOAPHeader   []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap/ header"`
+	SOAP12Body   WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ body"`
+	SOAP12Header []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ header"`
 }
 
 // WSDLOperation represents the contract of an entire operation or function.
 type WSDLOperation struct {
-	Name          string            `xml:"name,attr"`
-	Doc           string            `xml:"documentation"`
-	Input         WSDLInput         `xml:"input"`
-	Output        WSDLOutput        `xml:"output"`
-	Faults        []*WSDLFault      `xml:"fault"`
-	SOAPOperation WSDLSOAPOperation `xml:"http://schemas.xmlsoap.org/wsdl/soap/ operation"`
+	Name            string            `xml:"name,attr"`
+	Doc             string            `xml:"documentation"`
+	Input           WSDLInput         `xml:"input"`
+	Output          WSDLOutput        `xml:"output"`
+	Faults          []*WSDLFault      `xml:"fault"`
+	SOAPOperation   WSDLSOAPOperation `xml:"http://schemas.xmlsoap.org/wsdl/soap/ operation"`
+	SOAP12Operation WSDLSOAPOperation `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ operation"`
 }
 
 // WSDLPortType defines the service, operations that can be performed and the messages involved.
@@ -85,6 +93,8 @@ type WSDLPortType struct {
 	Name       string           `xml:"name,attr"`
 	Doc        string           `xml:"documentation"`
 	Operations []*WSDLOperation `xml:"http://schemas.xmlsoap.org/wsdl/ operation"`
+
+	namespace string
 }
 
 // WSDLSOAPBinding represents a SOAP binding to the web service.
@@ -95,8 +105,9 @@ type WSDLSOAPBinding struct {
 
 // WSDLSOAPOperation represents a service operation in SOAP terms.
 type WSDLSOAPOperation struct {
-	SOAPAction string `xml:"soapAction,attr"`
-	Style      string `xml:"style,attr"`
+	SOAPAction         string `xml:"soapAction,attr"`
+	SOAPActionRequired string `xml:"soapActionRequired,attr"`
+	Style              string `xml:"style,attr"`
 }
 
 // WSDLSOAPHeader defines the header for a SOAP service.
@@ -139,21 +150,25 @@ type WSDLSOAPAddress struct {
 	Location string `xml:"location,attr"`
 }
 
-// WSDLBinding defines only a SOAP binding and its operations
+// WSDLBinding defines a SOAP 1.1 or SOAP 1.2 binding and its operations
 type WSDLBinding struct {
-	Name        string           `xml:"name,attr"`
-	Type        string           `xml:"type,attr"`
-	Doc         string           `xml:"documentation"`
-	SOAPBinding WSDLSOAPBinding  `xml:"http://schemas.xmlsoap.org/wsdl/soap/ binding"`
-	Operations  []*WSDLOperation `xml:"http://schemas.xmlsoap.org/wsdl/ operation"`
+	Name          string           `xml:"name,attr"`
+	Type          string           `xml:"type,attr"`
+	Doc           string           `xml:"documentation"`
+	SOAPBinding   WSDLSOAPBinding  `xml:"http://schemas.xmlsoap.org/wsdl/soap/ binding"`
+	SOAP12Binding WSDLSOAPBinding  `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ binding"`
+	Operations    []*WSDLOperation `xml:"http://schemas.xmlsoap.org/wsdl/ operation"`
+
+	namespace string
 }
 
-// WSDLPort defines the properties for a SOAP port only.
+// WSDLPort defines the properties for a SOAP 1.1 or SOAP 1.2 port.
 type WSDLPort struct {
-	Name        string          `xml:"name,attr"`
-	Binding     string          `xml:"binding,attr"`
-	Doc         string          `xml:"documentation"`
-	SOAPAddress WSDLSOAPAddress `xml:"http://schemas.xmlsoap.org/wsdl/soap/ address"`
+	Name          string          `xml:"name,attr"`
+	Binding       string          `xml:"binding,attr"`
+	Doc           string          `xml:"documentation"`
+	SOAPAddress   WSDLSOAPAddress `xml:"http://schemas.xmlsoap.org/wsdl/soap/ address"`
+	SOAP12Address WSDLSOAPAddress `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ address"`
 }
 
 // WSDLService defines the list of SOAP services associated with the WSDL.
@@ -161,4 +176,6 @@ type WSDLService struct {
 	Name  string      `xml:"name,attr"`
 	Doc   string      `xml:"documentation"`
 	Ports []*WSDLPort `xml:"http://schemas.xmlsoap.org/wsdl/ port"`
+
+	namespace string
 }
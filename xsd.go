@@ -6,21 +6,29 @@ package gowsdl
 
 import (
 	"encoding/xml"
+	"strings"
 )
 
 // XSDSchema represents an entire Schema structure.
 type XSDSchema struct {
-	XMLName            xml.Name          `xml:"schema"`
-	Tns                string            `xml:"xmlns tns,attr"`
-	Xs                 string            `xml:"xmlns xs,attr"`
-	Version            string            `xml:"version,attr"`
-	TargetNamespace    string            `xml:"targetNamespace,attr"`
-	ElementFormDefault string            `xml:"elementFormDefault,attr"`
-	Includes           []*XSDInclude     `xml:"include"`
-	Imports            []*XSDImport      `xml:"import"`
-	Elements           []*XSDElement     `xml:"element"`
-	ComplexTypes       []*XSDComplexType `xml:"complexType"` //global
-	SimpleType         []*XSDSimpleType  `xml:"simpleType"`
+	XMLName              xml.Name             `xml:"schema"`
+	Tns                  string               `xml:"xmlns tns,attr"`
+	Xs                   string               `xml:"xmlns xs,attr"`
+	Version              string               `xml:"version,attr"`
+	TargetNamespace      string               `xml:"targetNamespace,attr"`
+	ElementFormDefault   string               `xml:"elementFormDefault,attr"`
+	AttributeFormDefault string               `xml:"attributeFormDefault,attr"`
+	Includes             []*XSDInclude        `xml:"include"`
+	Imports              []*XSDImport         `xml:"import"`
+	Elements             []*XSDElement        `xml:"element"`
+	Attributes           []*XSDAttribute      `xml:"attribute"`
+	ComplexTypes         []*XSDComplexType    `xml:"complexType"` //global
+	SimpleType           []*XSDSimpleType     `xml:"simpleType"`
+	Groups               []*XSDGroup          `xml:"group"`
+	AttributeGroups      []*XSDAttributeGroup `xml:"attributeGroup"`
+
+	// File or URL the schema was read from.
+	location string
 }
 
 // XSDInclude represents schema includes.
@@ -37,63 +45,247 @@ type XSDImport struct {
 
 // XSDElement represents a Schema element.
 type XSDElement struct {
-	XMLName     xml.Name        `xml:"element"`
-	Name        string          `xml:"name,attr"`
-	Doc         string          `xml:"annotation>documentation"`
-	Nillable    bool            `xml:"nillable,attr"`
-	Type        string          `xml:"type,attr"`
-	Ref         string          `xml:"ref,attr"`
-	MinOccurs   string          `xml:"minOccurs,attr"`
-	MaxOccurs   string          `xml:"maxOccurs,attr"`
-	ComplexType *XSDComplexType `xml:"complexType"` //local
-	SimpleType  *XSDSimpleType  `xml:"simpleType"`
-	Groups      []*XSDGroup     `xml:"group"`
+	XMLName           xml.Name        `xml:"element"`
+	Name              string          `xml:"name,attr"`
+	Doc               string          `xml:"annotation>documentation"`
+	Nillable          bool            `xml:"nillable,attr"`
+	Default           string          `xml:"default,attr"`
+	Fixed             string          `xml:"fixed,attr"`
+	Abstract          bool            `xml:"abstract,attr"`
+	Type              string          `xml:"type,attr"`
+	Ref               string          `xml:"ref,attr"`
+	SubstitutionGroup string          `xml:"substitutionGroup,attr"`
+	MinOccurs         string          `xml:"minOccurs,attr"`
+	MaxOccurs         string          `xml:"maxOccurs,attr"`
+	Form              string          `xml:"form,attr"`
+	ComplexType       *XSDComplexType `xml:"complexType"` //local
+	SimpleType        *XSDSimpleType  `xml:"simpleType"`
+	Groups            []*XSDGroup     `xml:"group"`
 }
 
 // XSDComplexType represents a Schema complex type.
 type XSDComplexType struct {
-	XMLName        xml.Name          `xml:"complexType"`
-	Abstract       bool              `xml:"abstract,attr"`
-	Name           string            `xml:"name,attr"`
-	Mixed          bool              `xml:"mixed,attr"`
-	Sequence       []XSDElement      `xml:"sequence>element"`
-	Choice         []XSDElement      `xml:"choice>element"`
-	SequenceChoice []XSDElement      `xml:"sequence>choice>element"`
-	All            []XSDElement      `xml:"all>element"`
-	ComplexContent XSDComplexContent `xml:"complexContent"`
-	SimpleContent  XSDSimpleContent  `xml:"simpleContent"`
-	Attributes     []*XSDAttribute   `xml:"attribute"`
+	XMLName         xml.Name             `xml:"complexType"`
+	Abstract        bool                 `xml:"abstract,attr"`
+	Name            string               `xml:"name,attr"`
+	Mixed           bool                 `xml:"mixed,attr"`
+	Sequence        *XSDParticle         `xml:"sequence"`
+	Choice          *XSDParticle         `xml:"choice"`
+	All             *XSDParticle         `xml:"all"`
+	Group           *XSDParticle         `xml:"group"`
+	ComplexContent  XSDComplexContent    `xml:"complexContent"`
+	SimpleContent   XSDSimpleContent     `xml:"simpleContent"`
+	Attributes      []*XSDAttribute      `xml:"attribute"`
+	AttributeGroups []*XSDAttributeGroup `xml:"attributeGroup"`
+	AnyAttribute    *XSDWildcard         `xml:"anyAttribute"`
+}
+
+// Content returns the particle holding the content model of the complex
+// type, or nil if the type has no element content.
+func (t *XSDComplexType) Content() *XSDParticle {
+	return firstParticle(t.Sequence, t.Choice, t.All, t.Group)
 }
 
 // XSDGroup element is used to define a group of elements to be used in complex type definitions.
 type XSDGroup struct {
-	Name     string       `xml:"name,attr"`
-	Ref      string       `xml:"ref,attr"`
-	Sequence []XSDElement `xml:"sequence>element"`
-	Choice   []XSDElement `xml:"choice>element"`
-	All      []XSDElement `xml:"all>element"`
+	Name      string       `xml:"name,attr"`
+	Ref       string       `xml:"ref,attr"`
+	MinOccurs string       `xml:"minOccurs,attr"`
+	MaxOccurs string       `xml:"maxOccurs,attr"`
+	Sequence  *XSDParticle `xml:"sequence"`
+	Choice    *XSDParticle `xml:"choice"`
+	All       *XSDParticle `xml:"all"`
+}
+
+// Content returns the compositor of a named group.
+func (g *XSDGroup) Content() *XSDParticle {
+	return firstParticle(g.Sequence, g.Choice, g.All)
+}
+
+// XSDParticle is a node of a content model: a sequence, choice or all
+// compositor with its children, an element, a group reference or a
+// wildcard.
+type XSDParticle struct {
+	Kind      string // sequence, choice, all, element, group or any
+	MinOccurs string
+	MaxOccurs string
+	Element   *XSDElement
+	Group     *XSDGroup
+	Any       *XSDWildcard
+	Particles []*XSDParticle
+}
+
+// UnmarshalXML decodes a particle keeping its children in document order.
+func (p *XSDParticle) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	p.Kind = start.Name.Local
+
+	switch p.Kind {
+	case "element":
+		p.Element = new(XSDElement)
+		if err := d.DecodeElement(p.Element, &start); err != nil {
+			return err
+		}
+		p.MinOccurs, p.MaxOccurs = p.Element.MinOccurs, p.Element.MaxOccurs
+		return nil
+	case "group":
+		p.Group = new(XSDGroup)
+		if err := d.DecodeElement(p.Group, &start); err != nil {
+			return err
+		}
+		p.MinOccurs, p.MaxOccurs = p.Group.MinOccurs, p.Group.MaxOccurs
+		return nil
+	case "any":
+		p.Any = new(XSDWildcard)
+		if err := d.DecodeElement(p.Any, &start); err != nil {
+			return err
+		}
+		p.MinOccurs, p.MaxOccurs = p.Any.MinOccurs, p.Any.MaxOccurs
+		return nil
+	}
+
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "minOccurs":
+			p.MinOccurs = attr.Value
+		case "maxOccurs":
+			p.MaxOccurs = attr.Value
+		}
+	}
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "sequence", "choice", "all", "element", "group", "any":
+				child := new(XSDParticle)
+				if err := d.DecodeElement(child, &t); err != nil {
+					return err
+				}
+				p.Particles = append(p.Particles, child)
+			default:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func firstParticle(particles ...*XSDParticle) *XSDParticle {
+	for _, p := range particles {
+		if p != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// XSDAttributeGroup element is used to define a group of attributes to be
+// used in complex type definitions.
+type XSDAttributeGroup struct {
+	Name            string               `xml:"name,attr"`
+	Ref             string               `xml:"ref,attr"`
+	Attributes      []*XSDAttribute      `xml:"attribute"`
+	AttributeGroups []*XSDAttributeGroup `xml:"attributeGroup"`
+	AnyAttribute    *XSDWildcard         `xml:"anyAttribute"`
+}
+
+// XSDWildcard represents an xs:any or xs:anyAttribute wildcard, matching the
+// elements or attributes of the namespaces it allows.
+type XSDWildcard struct {
+	Namespace       string `xml:"namespace,attr"`
+	ProcessContents string `xml:"processContents,attr"`
+	MinOccurs       string `xml:"minOccurs,attr"`
+	MaxOccurs       string `xml:"maxOccurs,attr"`
+}
+
+// NamespaceConstraint returns the namespace attribute of the wildcard,
+// ##any when missing.
+func (w *XSDWildcard) NamespaceConstraint() string {
+	if namespace := strings.Join(strings.Fields(w.Namespace), " "); namespace != "" {
+		return namespace
+	}
+	return "##any"
+}
+
+// Processing returns the processContents attribute of the wildcard, strict
+// when missing.
+func (w *XSDWildcard) Processing() string {
+	if w.ProcessContents == "" {
+		return "strict"
+	}
+	return w.ProcessContents
 }
 
 // XSDComplexContent element defines extensions or restrictions on a complex
 // type that contains mixed content or elements only.
 type XSDComplexContent struct {
-	XMLName   xml.Name     `xml:"complexContent"`
-	Extension XSDExtension `xml:"extension"`
+	XMLName     xml.Name              `xml:"complexContent"`
+	Mixed       bool                  `xml:"mixed,attr"`
+	Extension   XSDExtension          `xml:"extension"`
+	Restriction XSDComplexRestriction `xml:"restriction"`
 }
 
 // XSDSimpleContent element contains extensions or restrictions on a text-only
 // complex type or on a simple type as content and contains no elements.
 type XSDSimpleContent struct {
-	XMLName   xml.Name     `xml:"simpleContent"`
-	Extension XSDExtension `xml:"extension"`
+	XMLName     xml.Name             `xml:"simpleContent"`
+	Extension   XSDExtension         `xml:"extension"`
+	Restriction XSDSimpleRestriction `xml:"restriction"`
 }
 
 // XSDExtension element extends an existing simpleType or complexType element.
 type XSDExtension struct {
-	XMLName    xml.Name        `xml:"extension"`
-	Base       string          `xml:"base,attr"`
-	Attributes []*XSDAttribute `xml:"attribute"`
-	Sequence   []XSDElement    `xml:"sequence>element"`
+	XMLName         xml.Name             `xml:"extension"`
+	Base            string               `xml:"base,attr"`
+	Attributes      []*XSDAttribute      `xml:"attribute"`
+	AttributeGroups []*XSDAttributeGroup `xml:"attributeGroup"`
+	Sequence        *XSDParticle         `xml:"sequence"`
+	Choice          *XSDParticle         `xml:"choice"`
+	All             *XSDParticle         `xml:"all"`
+	Group           *XSDParticle         `xml:"group"`
+	AnyAttribute    *XSDWildcard         `xml:"anyAttribute"`
+}
+
+// Content returns the particle holding the content model added by the
+// extension, or nil if it only adds attributes.
+func (e *XSDExtension) Content() *XSDParticle {
+	return firstParticle(e.Sequence, e.Choice, e.All, e.Group)
+}
+
+// XSDComplexRestriction element restricts a complexType: it restates the
+// content model of the type and redeclares or prohibits its attributes.
+type XSDComplexRestriction struct {
+	Base            string               `xml:"base,attr"`
+	Attributes      []*XSDAttribute      `xml:"attribute"`
+	AttributeGroups []*XSDAttributeGroup `xml:"attributeGroup"`
+	Sequence        *XSDParticle         `xml:"sequence"`
+	Choice          *XSDParticle         `xml:"choice"`
+	All             *XSDParticle         `xml:"all"`
+	Group           *XSDParticle         `xml:"group"`
+	AnyAttribute    *XSDWildcard         `xml:"anyAttribute"`
+}
+
+// Content returns the particle holding the content model restated by the
+// restriction, or nil if it restates none.
+func (r *XSDComplexRestriction) Content() *XSDParticle {
+	return firstParticle(r.Sequence, r.Choice, r.All, r.Group)
+}
+
+// XSDSimpleRestriction element restricts a complexType with simple content:
+// it narrows the value with facets and redeclares or prohibits attributes.
+type XSDSimpleRestriction struct {
+	XSDRestriction
+	Attributes      []*XSDAttribute      `xml:"attribute"`
+	AttributeGroups []*XSDAttributeGroup `xml:"attributeGroup"`
+	AnyAttribute    *XSDWildcard         `xml:"anyAttribute"`
 }
 
 // XSDAttribute represent an element attribute. Simple elements cannot have
@@ -101,9 +293,16 @@ type XSDExtension struct {
 // complex type. But the attribute itself is always declared as a simple type.
 type XSDAttribute struct {
 	Name       string         `xml:"name,attr"`
+	Ref        string         `xml:"ref,attr"`
 	Doc        string         `xml:"annotation>documentation"`
 	Type       string         `xml:"type,attr"`
+	Use        string         `xml:"use,attr"`
+	Form       string         `xml:"form,attr"`
+	Default    string         `xml:"default,attr"`
+	Fixed      string         `xml:"fixed,attr"`
 	SimpleType *XSDSimpleType `xml:"simpleType"`
+	// Type of the items of a SOAP-encoded array, such as tns:Item[].
+	ArrayType string `xml:"http://schemas.xmlsoap.org/wsdl/ arrayType,attr"`
 }
 
 // XSDSimpleType element defines a simple type and specifies the constraints
@@ -111,19 +310,40 @@ type XSDAttribute struct {
 type XSDSimpleType struct {
 	Name        string         `xml:"name,attr"`
 	Restriction XSDRestriction `xml:"restriction"`
+	List        *XSDList       `xml:"list"`
+	Union       *XSDUnion      `xml:"union"`
+}
+
+// XSDList represents a Schema list: whitespace separated values of its item
+// type, given by reference or inline.
+type XSDList struct {
+	ItemType   string         `xml:"itemType,attr"`
+	SimpleType *XSDSimpleType `xml:"simpleType"`
+}
+
+// XSDUnion represents a Schema union of the member types it references and
+// of those declared inline.
+type XSDUnion struct {
+	MemberTypes string           `xml:"memberTypes,attr"`
+	SimpleTypes []*XSDSimpleType `xml:"simpleType"`
 }
 
 // XSDRestriction defines restrictions on a simpleType, simpleContent, or complexContent definition.
 type XSDRestriction struct {
-	Base         string                `xml:"base,attr"`
-	Enumeration  []XSDRestrictionValue `xml:"enumeration"`
-	Pattern      XSDRestrictionValue   `xml:"pattern"`
-	MinInclusive XSDRestrictionValue   `xml:"minInclusive"`
-	MaxInclusive XSDRestrictionValue   `xml:"maxInclusive"`
-	WhiteSpace   XSDRestrictionValue   `xml:"whitespace"`
-	Length       XSDRestrictionValue   `xml:"length"`
-	MinLength    XSDRestrictionValue   `xml:"minLength"`
-	MaxLength    XSDRestrictionValue   `xml:"maxLength"`
+	Base           string                `xml:"base,attr"`
+	Enumeration    []XSDRestrictionValue `xml:"enumeration"`
+	Pattern        []XSDRestrictionValue `xml:"pattern"`
+	MinInclusive   XSDRestrictionValue   `xml:"minInclusive"`
+	MaxInclusive   XSDRestrictionValue   `xml:"maxInclusive"`
+	MinExclusive   XSDRestrictionValue   `xml:"minExclusive"`
+	MaxExclusive   XSDRestrictionValue   `xml:"maxExclusive"`
+	TotalDigits    XSDRestrictionValue   `xml:"totalDigits"`
+	FractionDigits XSDRestrictionValue   `xml:"fractionDigits"`
+	WhiteSpace     XSDRestrictionValue   `xml:"whiteSpace"`
+	Length         XSDRestrictionValue   `xml:"length"`
+	MinLength      XSDRestrictionValue   `xml:"minLength"`
+	MaxLength      XSDRestrictionValue   `xml:"maxLength"`
+	Assertion      []XSDAssertion        `xml:"assertion"`
 }
 
 // XSDRestrictionValue represents a restriction value.
@@ -131,3 +351,10 @@ type XSDRestrictionValue struct {
 	Doc   string `xml:"annotation>documentation"`
 	Value string `xml:"value,attr"`
 }
+
+// XSDAssertion represents an XSD 1.1 assertion facet, an XPath 2.0
+// expression the value must satisfy.
+type XSDAssertion struct {
+	Doc  string `xml:"annotation>documentation"`
+	Test string `xml:"test,attr"`
+}